@@ -0,0 +1,254 @@
+package clean
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+)
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+var volumesFlag bool
+var imagesFlag bool
+var yesFlag bool
+var dryRunFlag bool
+
+// cleanPlan is everything clean stops and removes, in order
+type cleanPlan struct {
+	dependents []string
+	containers []types.Container
+	volumes    []string
+	images     []types.Container
+	kept       []string
+	skipped    []string
+}
+
+func (p *cleanPlan) empty() bool {
+	return len(p.dependents) == 0 && len(p.containers) == 0 && len(p.volumes) == 0 && len(p.images) == 0
+}
+
+var Command = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove containers, volumes and images of projects",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Clean")
+
+		projectsToClean, projectsErr := util.ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		if projectsErr != nil {
+			output.Warning(projectsErr.Error())
+			return
+		}
+
+		plan, planErr := makePlan(projectsToClean)
+		if planErr != nil {
+			output.Error(planErr.Error())
+			os.Exit(1)
+		}
+
+		output.Section("Plan")
+		if plan.empty() {
+			displayPlan(plan)
+			output.Plain("Nothing to clean")
+			return
+		}
+		displayPlan(plan)
+
+		if dryRunFlag {
+			return
+		}
+
+		if !yesFlag && !config.Confirm("Clean") {
+			output.Warning("Cancelled, pass --yes to clean without a prompt")
+			return
+		}
+
+		ctx := context.Background()
+
+		if len(plan.dependents) > 0 {
+			output.Section("Stopping dependents")
+			for _, projectKey := range plan.dependents {
+				output.Plain(projectKey)
+				util.StopProject(config.GetProjectByKey(projectKey))
+			}
+		}
+
+		output.Section("Removing")
+		for _, container := range plan.containers {
+			if removeErr := docker.RemoveContainer(ctx, container.ID); removeErr != nil {
+				output.Error(removeErr.Error())
+				continue
+			}
+			output.Ok(fmt.Sprintf("Removed container %s", docker.ContainerName(container)))
+		}
+
+		for _, volume := range plan.volumes {
+			if removeErr := docker.RemoveVolume(ctx, volume); removeErr != nil {
+				output.Error(removeErr.Error())
+				continue
+			}
+			output.Ok(fmt.Sprintf("Removed volume %s", volume))
+		}
+
+		for _, image := range plan.images {
+			if removeErr := docker.RemoveImage(ctx, image.ImageID); removeErr != nil {
+				output.Error(removeErr.Error())
+				continue
+			}
+			output.Ok(fmt.Sprintf("Removed image %s", image.Image))
+		}
+	},
+}
+
+// makePlan finds the containers of the projects, stopped ones included, with their volumes and
+// images when asked for, and the running projects that depend on them
+func makePlan(projects map[string]config.Project) (cleanPlan, error) {
+	plan := cleanPlan{}
+
+	containers, containerErr := docker.AllContainers(context.Background())
+	if containerErr != nil {
+		return plan, containerErr
+	}
+
+	projectKeys := make([]string, 0, len(projects))
+	for projectKey := range projects {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	planned := map[string]bool{}
+	for _, projectKey := range projectKeys {
+		project := projects[projectKey]
+		matches, matcherErr := project.ContainerMatcher()
+		if matcherErr != nil {
+			return plan, fmt.Errorf("%s: %s", projectKey, matcherErr.Error())
+		}
+		fallbackMatches, fallbackErr := project.FallbackContainerMatcher()
+		if fallbackErr != nil {
+			return plan, fmt.Errorf("%s: %s", projectKey, fallbackErr.Error())
+		}
+
+		for _, container := range containers {
+			if matches(container) && !planned[container.ID] {
+				plan.containers = append(plan.containers, container)
+				planned[container.ID] = true
+			}
+		}
+
+		// Containers only named like the project could belong to any stack, they are left to the user
+		for _, container := range containers {
+			if fallbackMatches(container) {
+				plan.skipped = append(plan.skipped, fmt.Sprintf("container %s, it may not belong to %s, remove it with docker rm if it does", docker.ContainerName(container), projectKey))
+			}
+		}
+	}
+
+	// Dependents are stopped last started first
+	dependents := []string{}
+	seen := map[string]bool{}
+	for _, projectKey := range projectKeys {
+		for _, dependentKey := range config.RunDependents(projectKey) {
+			if _, selected := projects[dependentKey]; !selected && !seen[dependentKey] {
+				dependents = append(dependents, dependentKey)
+				seen[dependentKey] = true
+			}
+		}
+	}
+	for dependentIndex := len(dependents) - 1; dependentIndex >= 0; dependentIndex-- {
+		dependent := config.GetProjectByKey(dependents[dependentIndex])
+		matches, matcherErr := dependent.ContainerMatcher()
+		if matcherErr != nil {
+			return plan, fmt.Errorf("%s: %s", dependents[dependentIndex], matcherErr.Error())
+		}
+		for _, container := range containers {
+			if container.State == "running" && matches(container) {
+				plan.dependents = append(plan.dependents, dependents[dependentIndex])
+				break
+			}
+		}
+	}
+
+	// Volumes and images other containers use stay
+	usedElsewhere := map[string][]string{}
+	for _, container := range containers {
+		if planned[container.ID] {
+			continue
+		}
+		for _, volume := range docker.ContainerVolumes(container) {
+			usedElsewhere[volume] = append(usedElsewhere[volume], docker.ContainerName(container))
+		}
+		usedElsewhere[container.ImageID] = append(usedElsewhere[container.ImageID], docker.ContainerName(container))
+	}
+
+	plannedResources := map[string]bool{}
+	for _, container := range plan.containers {
+		if volumesFlag {
+			for _, volume := range docker.ContainerVolumes(container) {
+				if plannedResources[volume] {
+					continue
+				}
+				plannedResources[volume] = true
+				if users, used := usedElsewhere[volume]; used {
+					plan.kept = append(plan.kept, fmt.Sprintf("volume %s, used by %s", volume, strings.Join(users, ", ")))
+					continue
+				}
+				plan.volumes = append(plan.volumes, volume)
+			}
+		}
+
+		if imagesFlag && !plannedResources[container.ImageID] {
+			plannedResources[container.ImageID] = true
+			if users, used := usedElsewhere[container.ImageID]; used {
+				plan.kept = append(plan.kept, fmt.Sprintf("image %s, used by %s", container.Image, strings.Join(users, ", ")))
+				continue
+			}
+			plan.images = append(plan.images, container)
+		}
+	}
+
+	return plan, nil
+}
+
+func displayPlan(plan cleanPlan) {
+	for _, projectKey := range plan.dependents {
+		output.Plain(fmt.Sprintf("Stop dependent %s", projectKey))
+	}
+
+	for _, container := range plan.containers {
+		output.Plain(fmt.Sprintf("Remove container %s (%s)", docker.ContainerName(container), container.State))
+	}
+
+	for _, volume := range plan.volumes {
+		output.Plain(fmt.Sprintf("Remove volume %s", volume))
+	}
+
+	for _, image := range plan.images {
+		output.Plain(fmt.Sprintf("Remove image %s", image.Image))
+	}
+
+	for _, kept := range plan.kept {
+		output.Warning(fmt.Sprintf("Keep %s", kept))
+	}
+
+	for _, skipped := range plan.skipped {
+		output.Warning(fmt.Sprintf("Skip %s", skipped))
+	}
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+	Command.Flags().BoolVar(&volumesFlag, "volumes", false, "also remove the volumes of the containers")
+	Command.Flags().BoolVar(&imagesFlag, "images", false, "also remove the images of the containers")
+	Command.Flags().BoolVarP(&yesFlag, "yes", "y", false, "clean without asking for confirmation")
+	Command.Flags().BoolVar(&dryRunFlag, "dry-run", false, "only show what would be removed")
+}
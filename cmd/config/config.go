@@ -1,11 +1,17 @@
 package config
 
 import (
+	"fmt"
 	"github.com/poloniex/polo-local-dev/config"
 	"github.com/poloniex/polo-local-dev/output"
 	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
 )
 
+var importGroupFlag string
+
 var Command = &cobra.Command{
 	Use:   "config",
 	Short: "Config management",
@@ -27,6 +33,175 @@ var reload = &cobra.Command{
 	},
 }
 
+var update = &cobra.Command{
+	Use:   "update",
+	Short: "Pull the latest config sources and reload",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Config")
+		output.Plain("Updating sources")
+
+		if updateErr := config.Update(); updateErr != nil {
+			output.Error(updateErr.Error())
+			return
+		}
+
+		output.Plain("Reloading from dist")
+
+		config.Reload()
+	},
+}
+
+var get = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	// Only the value goes to stdout, config checks go to stderr
+	Annotations: map[string]string{output.ValueOutputAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		value, getErr := config.GetSetting(args[0])
+		if getErr != nil {
+			output.Error(getErr.Error())
+			os.Exit(1)
+		}
+
+		fmt.Println(value)
+	},
+}
+
+var set = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Type-check a setting and write it to config.json",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Config")
+
+		if setErr := config.SetSetting(args[0], args[1]); setErr != nil {
+			output.Error(setErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok(fmt.Sprintf("%s = %s", args[0], args[1]))
+	},
+}
+
+var edit = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit config.json in $EDITOR, validated on save",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Config")
+
+		if editErr := config.Edit(); editErr != nil {
+			output.Error(editErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok("Config saved")
+	},
+}
+
+var show = &cobra.Command{
+	Use:   "show",
+	Short: "Display the effective settings and where each comes from",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Config")
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Key\tValue\tSource\tDescription\n")
+		for _, setting := range config.Settings {
+			value, _ := config.GetSetting(setting.Key)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", setting.Key, value, config.SettingSource(setting.Key), setting.Description)
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
+
+var importCompose = &cobra.Command{
+	Use:   "import-compose <path>",
+	Short: "Generate project configs from a docker-compose file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Import Compose")
+
+		result, importErr := config.ImportCompose(args[0], importGroupFlag)
+		if importErr != nil {
+			output.Error(importErr.Error())
+			os.Exit(1)
+		}
+
+		output.Plain(fmt.Sprintf("Wrote %s", result.File))
+
+		for _, projectKey := range result.Added {
+			output.Ok(fmt.Sprintf("Added %s", projectKey))
+		}
+		for _, projectKey := range result.Updated {
+			output.Ok(fmt.Sprintf("Updated %s", projectKey))
+		}
+		for _, projectKey := range result.Removed {
+			output.Warning(fmt.Sprintf("Removed %s, no longer in the compose file", projectKey))
+		}
+		for _, service := range result.Skipped {
+			output.Plain(fmt.Sprintf("Skipped %s", service))
+		}
+		if len(result.Unchanged) > 0 {
+			output.Plain(fmt.Sprintf("Unchanged: %s", strings.Join(result.Unchanged, ", ")))
+		}
+	},
+}
+
+var diff = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how the effective config differs from dist",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Config Diff")
+
+		diffs := config.Diff()
+		if len(diffs) == 0 {
+			output.Ok("Effective config matches dist")
+			return
+		}
+
+		for _, projectDiff := range diffs {
+			output.Section(projectDiff.Key)
+
+			if projectDiff.Added {
+				output.Plain("+ not in dist")
+				continue
+			}
+			if projectDiff.Removed {
+				output.Plain("- missing from effective config")
+				continue
+			}
+
+			for _, change := range projectDiff.Changes {
+				switch {
+				case change.Dist == "":
+					output.Plain(fmt.Sprintf("+ %s: %s", change.Path, change.Effective))
+				case change.Effective == "":
+					output.Plain(fmt.Sprintf("- %s: %s", change.Path, change.Dist))
+				default:
+					output.Plain(fmt.Sprintf("~ %s: %s -> %s", change.Path, change.Dist, change.Effective))
+				}
+			}
+		}
+	},
+}
+
 func init() {
 	Command.AddCommand(reload)
+	Command.AddCommand(update)
+	Command.AddCommand(diff)
+	Command.AddCommand(get)
+	Command.AddCommand(set)
+	Command.AddCommand(edit)
+	Command.AddCommand(show)
+	Command.AddCommand(importCompose)
+
+	importCompose.Flags().StringVarP(&importGroupFlag, "group", "g", "", "group for the imported projects")
 }
@@ -0,0 +1,261 @@
+package dev
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/git"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Changed files listed before a rebuild, the rest are counted
+	changedFilesShown = 5
+)
+
+var projectFlag string
+var includeFlag []string
+var excludeFlag []string
+var debounceFlag time.Duration
+
+// projectWatch follows the files of a project root that git does not ignore
+type projectWatch struct {
+	root    string
+	watcher *fsnotify.Watcher
+	ignore  *git.Ignore
+	include git.Globs
+	exclude git.Globs
+	folders int
+}
+
+var Command = &cobra.Command{
+	Use:   "dev",
+	Short: "Rebuild and restart a project whenever its files change",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Dev")
+
+		project := config.GetProjectByKey(projectFlag)
+		if project.Name == "" {
+			output.Error(fmt.Sprintf("Unknown project %s", projectFlag))
+			os.Exit(1)
+		}
+
+		if info, statErr := os.Stat(project.RootPath()); statErr != nil || !info.IsDir() {
+			output.Error(fmt.Sprintf("%s not found, clone it with: pld clone -p %s", project.RootPath(), projectFlag))
+			os.Exit(1)
+		}
+
+		include, exclude := includeFlag, excludeFlag
+		if project.Dev != nil {
+			include = append(append([]string{}, project.Dev.Include...), include...)
+			exclude = append(append([]string{}, project.Dev.Exclude...), exclude...)
+		}
+
+		watch, watchErr := newProjectWatch(project.RootPath(), include, exclude)
+		if watchErr != nil {
+			output.Error(watchErr.Error())
+			os.Exit(1)
+		}
+		defer watch.watcher.Close()
+
+		output.Section(project.Name)
+		output.Plain(fmt.Sprintf("Watching %s (%d folders)", watch.root, watch.folders))
+		if len(include) > 0 {
+			output.Plain(fmt.Sprintf("Include: %s", strings.Join(include, ", ")))
+		}
+		if len(exclude) > 0 {
+			output.Plain(fmt.Sprintf("Exclude: %s", strings.Join(exclude, ", ")))
+		}
+
+		// Changes are collected until none came in for the debounce time
+		debounce := time.NewTimer(debounceFlag)
+		debounce.Stop()
+		changed := map[string]bool{}
+
+		for {
+			select {
+			case event, open := <-watch.watcher.Events:
+				if !open {
+					return
+				}
+				if path, relevant := watch.handle(event); relevant {
+					changed[path] = true
+					debounce.Reset(debounceFlag)
+				}
+
+			case watcherErr, open := <-watch.watcher.Errors:
+				if !open {
+					return
+				}
+				output.Error(watcherErr.Error())
+
+			case <-debounce.C:
+				rebuild(project, changed)
+				changed = map[string]bool{}
+			}
+		}
+	},
+}
+
+func newProjectWatch(root string, include, exclude []string) (*projectWatch, error) {
+	watcher, watcherErr := fsnotify.NewWatcher()
+	if watcherErr != nil {
+		return nil, watcherErr
+	}
+
+	watch := &projectWatch{
+		root:    root,
+		watcher: watcher,
+		include: git.ParseGlobs(include),
+		exclude: git.ParseGlobs(exclude),
+	}
+	if reloadErr := watch.reload(); reloadErr != nil {
+		_ = watcher.Close()
+		return nil, reloadErr
+	}
+
+	return watch, nil
+}
+
+// reload reads the .gitignore files again and watches every folder they do not ignore
+func (w *projectWatch) reload() error {
+	ignore, ignoreErr := git.NewIgnore(w.root)
+	if ignoreErr != nil {
+		return ignoreErr
+	}
+	w.ignore = ignore
+	w.folders = 0
+
+	return w.addTree(w.root)
+}
+
+// addTree watches a folder and the folders below it, skipping ignored ones. Files are watched
+// through their folder.
+func (w *projectWatch) addTree(path string) error {
+	return filepath.Walk(path, func(walkPath string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			// Removed while walking
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		relPath := w.relative(walkPath)
+		if relPath != "" && !w.watched(relPath, true) {
+			return filepath.SkipDir
+		}
+
+		if relPath != "" {
+			if addErr := w.ignore.AddDir(relPath); addErr != nil {
+				return addErr
+			}
+		}
+
+		if addErr := w.watcher.Add(walkPath); addErr != nil {
+			return fmt.Errorf("%s: %s", walkPath, addErr.Error())
+		}
+		w.folders++
+
+		return nil
+	})
+}
+
+// handle follows new folders and .gitignore changes, and reports whether an event changed a file
+// that is watched
+func (w *projectWatch) handle(event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+
+	relPath := w.relative(event.Name)
+	isDir := false
+	if info, statErr := os.Stat(event.Name); statErr == nil {
+		isDir = info.IsDir()
+	}
+
+	if !w.watched(relPath, isDir) {
+		return "", false
+	}
+
+	if filepath.Base(relPath) == ".gitignore" {
+		if reloadErr := w.reload(); reloadErr != nil {
+			output.Error(reloadErr.Error())
+		}
+	} else if isDir && event.Op&fsnotify.Create != 0 {
+		if addErr := w.addTree(event.Name); addErr != nil {
+			output.Error(addErr.Error())
+		}
+	}
+
+	return relPath, true
+}
+
+// watched reports whether changes to a path count. Ignored and excluded paths never do, include
+// globs narrow the files that do.
+func (w *projectWatch) watched(relPath string, isDir bool) bool {
+	if w.ignore.Ignored(relPath, isDir) || w.exclude.Match(relPath, isDir) {
+		return false
+	}
+
+	return isDir || len(w.include) == 0 || w.include.Match(relPath, false)
+}
+
+func (w *projectWatch) relative(path string) string {
+	relPath, relErr := filepath.Rel(w.root, path)
+	if relErr != nil || relPath == "." {
+		return ""
+	}
+
+	return relPath
+}
+
+// rebuild runs the build commands of the project, then its run commands, waiting for it to be
+// healthy. A failed build leaves the running container as it is.
+func rebuild(project config.Project, changed map[string]bool) {
+	output.Section(fmt.Sprintf("%s Rebuild", time.Now().Format("15:04:05")))
+
+	changedFiles := make([]string, 0, len(changed))
+	for path := range changed {
+		changedFiles = append(changedFiles, path)
+	}
+	sort.Strings(changedFiles)
+	if len(changedFiles) > changedFilesShown {
+		changedFiles = append(changedFiles[:changedFilesShown], fmt.Sprintf("and %d more", len(changed)-changedFilesShown))
+	}
+	output.Plain(fmt.Sprintf("Changed: %s", strings.Join(changedFiles, ", ")))
+
+	if !util.BuildProject(project) {
+		output.Error("Build failed, the running container is left as it is")
+		output.Bell()
+		return
+	}
+
+	if !util.StartProject(project) {
+		output.Error("Start failed")
+		output.Bell()
+		return
+	}
+
+	output.Ok("Up to date, watching for changes")
+}
+
+func init() {
+	Command.Flags().StringVarP(&projectFlag, "project", "p", "", "project")
+	_ = Command.MarkFlagRequired("project")
+	Command.Flags().StringSliceVar(&includeFlag, "include", nil, "only watch files matching these globs, in .gitignore syntax")
+	Command.Flags().StringSliceVar(&excludeFlag, "exclude", nil, "never watch files matching these globs, in .gitignore syntax")
+	Command.Flags().DurationVar(&debounceFlag, "debounce", 500*time.Millisecond, "quiet time after a change before rebuilding")
+}
@@ -6,12 +6,16 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/hashicorp/go-version"
 	"github.com/poloniex/polo-local-dev/cmd/util/aws"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
 	"github.com/poloniex/polo-local-dev/git"
 	"github.com/poloniex/polo-local-dev/output"
 	"github.com/spf13/cobra"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
+	"strings"
 )
 
 var (
@@ -44,19 +48,29 @@ var Command = &cobra.Command{
 
 		output.Section("Environment Variables")
 
-		for envVarKey, envVarVal := range doctorEnvironmentVariables {
-			if os.Getenv(envVarKey) == "" {
+		// The active profile's expectations replace the defaults
+		expectedEnvironmentVariables := doctorEnvironmentVariables
+		if config.ActiveProfile.Doctor != nil && len(config.ActiveProfile.Doctor.Env) > 0 {
+			expectedEnvironmentVariables = config.ActiveProfile.Doctor.Env
+		}
+
+		for envVarKey, envVarVal := range expectedEnvironmentVariables {
+			if config.Getenv(envVarKey) == "" {
 				output.Warning(fmt.Sprintf("doctor env var missing: %s", envVarKey))
 				continue
 			}
-			if os.Getenv(envVarKey) != envVarVal {
-				output.Warning(fmt.Sprintf("doctor env var: %s, got: %s, expected: %s", envVarKey, os.Getenv(envVarKey), envVarVal))
+			if config.Getenv(envVarKey) != envVarVal {
+				output.Warning(fmt.Sprintf("doctor env var: %s, got: %s, expected: %s", envVarKey, config.Getenv(envVarKey), envVarVal))
 				continue
 			}
 
 			output.Ok(envVarKey)
 		}
 
+		output.Section("Ports")
+
+		doctorPorts()
+
 		output.Section("Docker")
 
 		cli, dockerClientErr := client.NewClientWithOpts(client.FromEnv)
@@ -89,6 +103,10 @@ var Command = &cobra.Command{
 			output.Warning(fmt.Sprintf("Version: %s < %s", installedVersion, minDockerVersion))
 		}
 
+		output.Section("Networks")
+
+		doctorNetworks()
+
 		output.Section("Python")
 
 		pythonVersionCommand := exec.Command("python", "--version")
@@ -137,3 +155,91 @@ var Command = &cobra.Command{
 
 	},
 }
+
+// doctorNetworks reports declared networks that are missing, differ from their definition, or have
+// a subnet overlapping another network
+func doctorNetworks() {
+	networks, networkListErr := docker.Networks(context.Background())
+	if networkListErr != nil {
+		output.Error(networkListErr.Error())
+		return
+	}
+
+	users := config.NetworkUsers()
+	if len(users) == 0 {
+		output.Plain("No networks declared")
+		return
+	}
+
+	networkNames := []string{}
+	for networkName := range users {
+		networkNames = append(networkNames, networkName)
+	}
+	sort.Strings(networkNames)
+
+	for nameIndex, networkName := range networkNames {
+		spec := config.NetworkSpec(networkName)
+		healthy := true
+
+		existing, findErr := docker.FindNetwork(context.Background(), networkName)
+		if findErr != nil {
+			output.Error(findErr.Error())
+			return
+		}
+
+		if existing == nil {
+			output.Warning(fmt.Sprintf("network %s missing, start creates it", networkName))
+			healthy = false
+		} else if checkErr := docker.CheckNetwork(*existing, spec); checkErr != nil {
+			output.Warning(checkErr.Error())
+			healthy = false
+		}
+
+		if spec.Subnet != "" {
+			for _, other := range networks {
+				if other.Name == networkName {
+					continue
+				}
+				for _, subnet := range docker.NetworkSubnets(other) {
+					if docker.SubnetsOverlap(spec.Subnet, subnet) {
+						output.Warning(fmt.Sprintf("network %s subnet %s overlaps network %s (%s)", networkName, spec.Subnet, other.Name, subnet))
+						healthy = false
+					}
+				}
+			}
+
+			for _, otherName := range networkNames[nameIndex+1:] {
+				otherSpec := config.NetworkSpec(otherName)
+				if otherSpec.Subnet != "" && docker.SubnetsOverlap(spec.Subnet, otherSpec.Subnet) {
+					output.Warning(fmt.Sprintf("network %s subnet %s overlaps declared network %s (%s)", networkName, spec.Subnet, otherName, otherSpec.Subnet))
+					healthy = false
+				}
+			}
+		}
+
+		if healthy {
+			output.Ok(networkName)
+		}
+	}
+}
+
+// doctorPorts reports ports more than one project declares
+func doctorPorts() {
+	users := config.PortUsers()
+	if len(users) == 0 {
+		output.Plain("No ports declared")
+		return
+	}
+
+	duplicates := false
+	for _, port := range config.SortedPorts(users) {
+		if len(users[port]) > 1 {
+			output.Warning(fmt.Sprintf("port %s declared by %s", port, strings.Join(users[port], ", ")))
+			duplicates = true
+		}
+	}
+
+	if !duplicates {
+		output.Ok(fmt.Sprintf("%d ports declared, each by one project", len(users)))
+	}
+}
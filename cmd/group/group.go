@@ -0,0 +1,71 @@
+package group
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// GroupListing is a group as listed by `group list`
+type GroupListing struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	Projects []string `json:"projects"`
+}
+
+var Command = &cobra.Command{
+	Use:   "group",
+	Short: "Project groups",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "List groups with their member counts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Groups")
+
+		listings := []GroupListing{}
+		for groupName, members := range config.GetGroups() {
+			listings = append(listings, GroupListing{
+				Name:     groupName,
+				Count:    len(members),
+				Projects: members,
+			})
+		}
+		sort.Slice(listings, func(i, j int) bool {
+			return listings[i].Name < listings[j].Name
+		})
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(listings); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Group\tProjects\n")
+		for _, listing := range listings {
+			_, _ = fmt.Fprintf(w, "%s\t%d\n", listing.Name, listing.Count)
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
+
+func init() {
+	Command.AddCommand(list)
+}
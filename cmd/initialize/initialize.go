@@ -0,0 +1,49 @@
+package initialize
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var workspaceRootFlag string
+
+var Command = &cobra.Command{
+	Use:   "init",
+	Short: "Initialize pld without prompting",
+	Long:  "Writes the common config and installs project configs. The workspace root is taken from --workspace-root, then PLD_WORKSPACE_ROOT. Set PLD_HOME to use a config folder other than ~/.pld/.",
+	Args:  cobra.NoArgs,
+
+	// Config is loaded by this command itself, after the common config is written
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Init")
+
+		workspaceRoot := workspaceRootFlag
+		if workspaceRoot == "" {
+			workspaceRoot = os.Getenv("PLD_WORKSPACE_ROOT")
+		}
+		if workspaceRoot == "" {
+			output.Error("--workspace-root or PLD_WORKSPACE_ROOT is required")
+			os.Exit(1)
+		}
+
+		if initErr := config.Init(workspaceRoot); initErr != nil {
+			output.Error(initErr.Error())
+			os.Exit(1)
+		}
+		output.Ok(fmt.Sprintf("Workspace root: %s", workspaceRoot))
+
+		if loadErr := config.Load(); loadErr != nil {
+			output.Error(loadErr.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	Command.Flags().StringVar(&workspaceRootFlag, "workspace-root", "", "workspace root folder")
+}
@@ -0,0 +1,152 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// NetworkListing is a network as listed by `network ls`
+type NetworkListing struct {
+	Name       string   `json:"name"`
+	Driver     string   `json:"driver"`
+	Subnets    []string `json:"subnets"`
+	Containers int      `json:"containers"`
+	Status     string   `json:"status"`
+	Projects   []string `json:"projects"`
+}
+
+var Command = &cobra.Command{
+	Use:   "network",
+	Short: "Docker networks used by projects",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var ls = &cobra.Command{
+	Use:   "ls",
+	Short: "List pld-owned and declared networks",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Networks")
+
+		managed, managedErr := docker.ManagedNetworks(context.Background())
+		if managedErr != nil {
+			output.Error(managedErr.Error())
+			os.Exit(1)
+		}
+
+		users := config.NetworkUsers()
+		listings := map[string]NetworkListing{}
+
+		for _, resource := range managed {
+			status := "ok"
+			if _, declared := users[resource.Name]; !declared {
+				status = "undeclared"
+			} else if checkErr := docker.CheckNetwork(resource, config.NetworkSpec(resource.Name)); checkErr != nil {
+				status = "conflict"
+			}
+
+			listings[resource.Name] = NetworkListing{
+				Name:       resource.Name,
+				Driver:     resource.Driver,
+				Subnets:    docker.NetworkSubnets(resource),
+				Containers: len(resource.Containers),
+				Status:     status,
+				Projects:   users[resource.Name],
+			}
+		}
+
+		// Declared networks that do not exist, or exist without being created by pld
+		for networkName, projects := range users {
+			if _, listed := listings[networkName]; listed {
+				continue
+			}
+
+			listing := NetworkListing{Name: networkName, Status: "missing", Projects: projects, Subnets: []string{}}
+			existing, findErr := docker.FindNetwork(context.Background(), networkName)
+			if findErr != nil {
+				output.Error(findErr.Error())
+				os.Exit(1)
+			}
+			if existing != nil {
+				listing.Driver = existing.Driver
+				listing.Subnets = docker.NetworkSubnets(*existing)
+				listing.Status = "unmanaged"
+				if checkErr := docker.CheckNetwork(*existing, config.NetworkSpec(networkName)); checkErr != nil {
+					listing.Status = "conflict"
+				}
+			}
+			listings[networkName] = listing
+		}
+
+		sortedListings := []NetworkListing{}
+		for _, listing := range listings {
+			sortedListings = append(sortedListings, listing)
+		}
+		sort.Slice(sortedListings, func(i, j int) bool {
+			return sortedListings[i].Name < sortedListings[j].Name
+		})
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(sortedListings); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(sortedListings) == 0 {
+			output.Plain("No networks")
+			return
+		}
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Name\tDriver\tSubnet\tContainers\tStatus\tProjects\n")
+		for _, listing := range sortedListings {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", listing.Name, listing.Driver, strings.Join(listing.Subnets, ", "), listing.Containers, listing.Status, strings.Join(listing.Projects, ", "))
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
+
+var prune = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove pld-owned networks no container uses",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Networks")
+
+		pruned, pruneErr := docker.PruneNetworks(context.Background())
+		if pruneErr != nil {
+			output.Error(pruneErr.Error())
+			os.Exit(1)
+		}
+
+		if len(pruned) == 0 {
+			output.Plain("Nothing to prune")
+			return
+		}
+
+		for _, networkName := range pruned {
+			output.Ok(fmt.Sprintf("Removed %s", networkName))
+		}
+	},
+}
+
+func init() {
+	Command.AddCommand(ls)
+	Command.AddCommand(prune)
+}
@@ -0,0 +1,114 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// Port statuses: bound by a declaring project, free, bound by something else, declared by more
+	// than one project, or published by a project container without being declared
+	PortStatusOk         = "ok"
+	PortStatusFree       = "free"
+	PortStatusConflict   = "conflict"
+	PortStatusDuplicate  = "duplicate"
+	PortStatusUndeclared = "undeclared"
+)
+
+// PortListing is a host port as listed by `ports`
+type PortListing struct {
+	config.Port
+	Projects []string         `json:"projects"`
+	Binding  util.PortBinding `json:"binding"`
+	Status   string           `json:"status"`
+}
+
+var Command = &cobra.Command{
+	Use:   "ports",
+	Short: "List declared host ports and what is bound to them",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Ports")
+
+		// Without docker, ports are still checked, only not named after containers
+		containers, containerErr := docker.Containers(context.Background())
+		if containerErr != nil {
+			output.Warning(containerErr.Error())
+		}
+
+		users := config.PortUsers()
+		listings := []PortListing{}
+		for _, port := range config.SortedPorts(users) {
+			listing := PortListing{Port: port, Projects: users[port], Binding: util.FindPortBinding(port, containers)}
+
+			switch {
+			case len(listing.Projects) > 1:
+				listing.Status = PortStatusDuplicate
+			case !listing.Binding.Bound:
+				listing.Status = PortStatusFree
+			case listing.Binding.Project == listing.Projects[0]:
+				listing.Status = PortStatusOk
+			default:
+				listing.Status = PortStatusConflict
+			}
+
+			listings = append(listings, listing)
+		}
+
+		// Ports project containers publish without declaring them
+		undeclared := map[config.Port][]string{}
+		for _, container := range containers {
+			projectKey := config.ContainerProject(container)
+			if projectKey == "" {
+				continue
+			}
+
+			for _, published := range docker.ContainerPublishedPorts(container) {
+				port := config.Port{Port: published.Port, Protocol: published.Protocol}
+				if _, declared := users[port]; !declared {
+					undeclared[port] = []string{projectKey}
+				}
+			}
+		}
+		for _, port := range config.SortedPorts(undeclared) {
+			listings = append(listings, PortListing{
+				Port:     port,
+				Projects: undeclared[port],
+				Binding:  util.FindPortBinding(port, containers),
+				Status:   PortStatusUndeclared,
+			})
+		}
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(listings); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(listings) == 0 {
+			output.Plain("No ports declared")
+			return
+		}
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Port\tProjects\tBound by\tStatus\n")
+		for _, listing := range listings {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", listing.Port, strings.Join(listing.Projects, ", "), listing.Binding.Describe(), listing.Status)
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
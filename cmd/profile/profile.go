@@ -0,0 +1,112 @@
+package profile
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var Command = &cobra.Command{
+	Use:   "profile",
+	Short: "Workspace profiles",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var use = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Profile")
+
+		if useErr := config.UseProfile(args[0]); useErr != nil {
+			output.Error(useErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok(fmt.Sprintf("Using profile %s", args[0]))
+	},
+}
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Profiles")
+
+		profiles, profilesErr := config.Profiles()
+		if profilesErr != nil {
+			output.Error(profilesErr.Error())
+			os.Exit(1)
+		}
+
+		if len(profiles) == 0 {
+			output.Plain("No profiles, create one with: pld profile create <name>")
+			return
+		}
+
+		for _, name := range profiles {
+			if name == config.ActiveProfileName {
+				output.Ok(fmt.Sprintf("%s (active)", name))
+			} else {
+				output.Plain(name)
+			}
+		}
+	},
+}
+
+var show = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Display a profile, the active one by default",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Profile")
+
+		name := config.ActiveProfileName
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" {
+			output.Warning("No active profile")
+			return
+		}
+
+		profile, profileErr := config.LoadProfile(name)
+		if profileErr != nil {
+			output.Error(profileErr.Error())
+			os.Exit(1)
+		}
+
+		output.Section(name)
+		output.Plain(profile.Display())
+	},
+}
+
+var create = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile from the current workspace root",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Profile")
+
+		if createErr := config.CreateProfile(args[0]); createErr != nil {
+			output.Error(createErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok(fmt.Sprintf("Created profile %s", args[0]))
+	},
+}
+
+func init() {
+	Command.AddCommand(use)
+	Command.AddCommand(list)
+	Command.AddCommand(show)
+	Command.AddCommand(create)
+}
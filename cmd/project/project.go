@@ -1,14 +1,37 @@
 package project
 
 import (
+	"errors"
+	"fmt"
+	"github.com/manifoldco/promptui"
 	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
 	"github.com/poloniex/polo-local-dev/output"
 	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 )
 
 var groupFlag string
 var projectFlag string
 var allFlag bool
+var repoFlag string
+var dependsOnFlag string
+
+// Picked to finish a selection loop
+var selectDone = "(done)"
+
+// ProjectListing is a project as listed by `project list`
+type ProjectListing struct {
+	Key       string           `json:"key"`
+	Name      string           `json:"name"`
+	Repo      string           `json:"repo"`
+	Groups    []string         `json:"groups"`
+	DependsOn config.DependsOn `json:"depends_on"`
+	Source    string           `json:"source"`
+}
 
 var Command = &cobra.Command{
 	Use:   "project",
@@ -32,16 +55,254 @@ var projectDetails = &cobra.Command{
 			return
 		}
 
-		for _, proj := range projects {
+		for projectKey, proj := range projects {
+			provenance := config.GetProvenance(projectKey)
+
 			output.Section(proj.Name)
 			output.Plain(proj.Display())
+			output.Plain(provenance.Display())
+		}
+
+	},
+}
+
+var projectList = &cobra.Command{
+	Use:   "list",
+	Short: "List projects, filtered by group, repo or dependency",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Projects")
+
+		listings := []ProjectListing{}
+		for projectKey, proj := range config.ProjectConfigs {
+			if groupFlag != "" && !contains(proj.Groups, groupFlag) {
+				continue
+			}
+			if repoFlag != "" && proj.GetRepoName() != repoFlag {
+				continue
+			}
+			if dependsOnFlag != "" && !contains(proj.Dependencies(""), dependsOnFlag) {
+				continue
+			}
+
+			listings = append(listings, ProjectListing{
+				Key:       projectKey,
+				Name:      proj.Name,
+				Repo:      proj.GetRepoName(),
+				Groups:    proj.Groups,
+				DependsOn: proj.DependsOn,
+				Source:    config.GetProvenance(projectKey).Source,
+			})
+		}
+		sort.Slice(listings, func(i, j int) bool {
+			return listings[i].Key < listings[j].Key
+		})
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(listings); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(listings) == 0 {
+			output.Warning("no projects based on parameters")
+			return
+		}
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Key\tName\tRepo\tGroups\tSource\n")
+		for _, listing := range listings {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", listing.Key, listing.Name, listing.Repo, strings.Join(listing.Groups, ", "), listing.Source)
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
+
+var projectAdd = &cobra.Command{
+	Use:   "add",
+	Short: "Scaffold a new project config",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Add Project")
+
+		if !config.IsInteractive() {
+			output.Error("project add needs a terminal to prompt")
+			os.Exit(1)
 		}
 
+		key, proj, promptErr := promptProject()
+		if promptErr != nil {
+			output.Error(promptErr.Error())
+			os.Exit(1)
+		}
+
+		projectFile, addErr := config.AddProject(key, proj)
+		if addErr != nil {
+			output.Error(addErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok(fmt.Sprintf("Wrote %s", projectFile))
+		output.Section(key)
+		output.Plain(proj.Display())
 	},
 }
 
+func promptProject() (string, config.Project, error) {
+	var proj config.Project
+
+	key, keyErr := promptText("Project key", true)
+	if keyErr != nil {
+		return "", proj, keyErr
+	}
+	if _, exists := config.ProjectConfigs[key]; exists {
+		return "", proj, fmt.Errorf("project %s already exists", key)
+	}
+
+	var promptErr error
+	if proj.Name, promptErr = promptText("System name", true); promptErr != nil {
+		return "", proj, promptErr
+	}
+	if proj.Repo, promptErr = promptText("Repo", true); promptErr != nil {
+		return "", proj, promptErr
+	}
+
+	groupNames := []string{}
+	for groupName := range config.GetGroups() {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+	output.Plain(fmt.Sprintf("Existing groups: %s", strings.Join(groupNames, ", ")))
+
+	groups, groupsErr := promptText("Groups (comma separated)", false)
+	if groupsErr != nil {
+		return "", proj, groupsErr
+	}
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			proj.Groups = append(proj.Groups, group)
+		}
+	}
+
+	projectKeys := []string{}
+	for projectKey := range config.ProjectConfigs {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	if proj.DependsOn.Compile, promptErr = promptSelectMany("Build dependency", projectKeys); promptErr != nil {
+		return "", proj, promptErr
+	}
+	if proj.DependsOn.Run, promptErr = promptSelectMany("Run dependency", projectKeys); promptErr != nil {
+		return "", proj, promptErr
+	}
+	if proj.BuildCmd, promptErr = promptCommands("Build command"); promptErr != nil {
+		return "", proj, promptErr
+	}
+	if proj.RunCmd, promptErr = promptCommands("Run command"); promptErr != nil {
+		return "", proj, promptErr
+	}
+
+	return key, proj, nil
+}
+
+func promptText(label string, required bool) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Validate: func(input string) error {
+			if required && strings.TrimSpace(input) == "" {
+				return errors.New("required")
+			}
+			return nil
+		},
+	}
+
+	value, promptErr := prompt.Run()
+
+	return strings.TrimSpace(value), promptErr
+}
+
+// promptSelectMany picks items one at a time until done is selected
+func promptSelectMany(label string, items []string) ([]string, error) {
+	picked := []string{}
+
+	for {
+		remaining := []string{selectDone}
+		for _, item := range items {
+			if !contains(picked, item) {
+				remaining = append(remaining, item)
+			}
+		}
+		if len(remaining) == 1 {
+			return picked, nil
+		}
+
+		selectPrompt := promptui.Select{
+			Label: label,
+			Items: remaining,
+			Size:  10,
+		}
+
+		_, selected, promptErr := selectPrompt.Run()
+		if promptErr != nil {
+			return nil, promptErr
+		}
+		if selected == selectDone {
+			return picked, nil
+		}
+
+		picked = append(picked, selected)
+	}
+}
+
+// promptCommands asks for commands until an empty one is entered
+func promptCommands(label string) ([]config.ShellCommand, error) {
+	commands := []config.ShellCommand{}
+
+	for {
+		command, commandErr := promptText(fmt.Sprintf("%s (empty to finish)", label), false)
+		if commandErr != nil {
+			return nil, commandErr
+		}
+		if command == "" {
+			return commands, nil
+		}
+
+		pathPrompt := promptui.Prompt{
+			Label:   "Path",
+			Default: "#PROJECT_ROOT#",
+		}
+		path, pathErr := pathPrompt.Run()
+		if pathErr != nil {
+			return nil, pathErr
+		}
+
+		commands = append(commands, config.ShellCommand{Command: command, Path: path})
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
 func init() {
 	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
 
+	projectList.Flags().StringVarP(&repoFlag, "repo", "r", "", "filter by repo")
+	projectList.Flags().StringVarP(&dependsOnFlag, "depends-on", "d", "", "filter by dependency")
+
 	Command.AddCommand(projectDetails)
+	Command.AddCommand(projectList)
+	Command.AddCommand(projectAdd)
 }
@@ -2,13 +2,28 @@ package cmd
 
 import (
 	"github.com/poloniex/polo-local-dev/cmd/build"
+	"github.com/poloniex/polo-local-dev/cmd/clean"
 	"github.com/poloniex/polo-local-dev/cmd/clone"
 	"github.com/poloniex/polo-local-dev/cmd/config"
 	"github.com/poloniex/polo-local-dev/cmd/dependency"
+	"github.com/poloniex/polo-local-dev/cmd/dev"
 	"github.com/poloniex/polo-local-dev/cmd/doctor"
 	"github.com/poloniex/polo-local-dev/cmd/fork"
+	"github.com/poloniex/polo-local-dev/cmd/group"
+	"github.com/poloniex/polo-local-dev/cmd/initialize"
+	"github.com/poloniex/polo-local-dev/cmd/network"
+	"github.com/poloniex/polo-local-dev/cmd/ports"
+	"github.com/poloniex/polo-local-dev/cmd/profile"
 	"github.com/poloniex/polo-local-dev/cmd/project"
+	"github.com/poloniex/polo-local-dev/cmd/run"
+	"github.com/poloniex/polo-local-dev/cmd/snapshot"
 	"github.com/poloniex/polo-local-dev/cmd/start"
+	"github.com/poloniex/polo-local-dev/cmd/stats"
+	"github.com/poloniex/polo-local-dev/cmd/stop"
+	"github.com/poloniex/polo-local-dev/cmd/test"
+	"github.com/poloniex/polo-local-dev/cmd/watch"
+	pldconfig "github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
 	"github.com/spf13/cobra"
 	"log"
 	"os"
@@ -18,6 +33,30 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   "pld",
 		Short: "Poloniex Local Dev Toolkit",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+
+			if jsonFlag, _ := cmd.Flags().GetBool("json"); jsonFlag {
+				output.SetJSON(true)
+			}
+			if _, valueOutput := cmd.Annotations[output.ValueOutputAnnotation]; valueOutput {
+				output.SetStderr()
+			}
+			output.Newline()
+
+			// Help needs no config, so never block it on first-run setup
+			if cmd == cmd.Root() || cmd.Name() == "help" || cmd.Name() == "completion" {
+				return
+			}
+
+			if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+				pldconfig.SetProfileOverride(profile)
+			}
+
+			if loadErr := pldconfig.Load(); loadErr != nil {
+				output.Error(loadErr.Error())
+				os.Exit(1)
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
 		},
@@ -33,9 +72,15 @@ func Execute() {
 
 func init() {
 
+	// Init
+	rootCmd.AddCommand(initialize.Command)
+
 	// Config
 	rootCmd.AddCommand(config.Command)
 
+	// Profile
+	rootCmd.AddCommand(profile.Command)
+
 	// Doctor
 	rootCmd.AddCommand(doctor.Command)
 
@@ -48,16 +93,50 @@ func init() {
 	// Start
 	rootCmd.AddCommand(start.Command)
 
+	// Stop
+	rootCmd.AddCommand(stop.Command)
+
 	// Build
 	rootCmd.AddCommand(build.Command)
 
+	// Run
+	rootCmd.AddCommand(run.Command)
+
+	// Test
+	rootCmd.AddCommand(test.Command)
+
+	// Dev
+	rootCmd.AddCommand(dev.Command)
+
 	// Dependency
 	rootCmd.AddCommand(dependency.Command)
 
 	// Project
 	rootCmd.AddCommand(project.Command)
 
+	// Group
+	rootCmd.AddCommand(group.Command)
+
+	// Network
+	rootCmd.AddCommand(network.Command)
+
+	// Ports
+	rootCmd.AddCommand(ports.Command)
+
+	// Clean
+	rootCmd.AddCommand(clean.Command)
+
+	// Snapshot
+	rootCmd.AddCommand(snapshot.Command)
+
+	// Stats
+	rootCmd.AddCommand(stats.Command)
+
+	// Watch
+	rootCmd.AddCommand(watch.Command)
+
 	// Global flags
 	rootCmd.PersistentFlags().BoolP("json", "j", false, "JSON output")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().String("profile", "", "Workspace profile to use for this command")
 }
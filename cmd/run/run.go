@@ -0,0 +1,49 @@
+package run
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+var ignoreDepsFlag bool
+var listFlag bool
+
+var Command = &cobra.Command{
+	Use:   "run <phase>",
+	Short: "Run a phase of projects in dependency order",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listFlag {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if listFlag {
+			output.Title("Phases")
+			output.Plain(strings.Join(config.PhaseNames(), "\n"))
+			return
+		}
+
+		phase := args[0]
+		output.Title(fmt.Sprintf("Run %s", phase))
+
+		if !util.RunPhase(phase, groupFlag, projectFlag, allFlag, ignoreDepsFlag) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+	util.DependencyFlags(Command, &ignoreDepsFlag)
+	Command.Flags().BoolVarP(&listFlag, "list", "l", false, "list the known phases")
+}
@@ -0,0 +1,285 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/git"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var projectFlag string
+
+var Command = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and restore project volumes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var save = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save the volumes of a project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Snapshot")
+
+		ctx := context.Background()
+
+		project := config.GetProjectByKey(projectFlag)
+		if project.Name == "" {
+			output.Error(fmt.Sprintf("Unknown project %s", projectFlag))
+			os.Exit(1)
+		}
+
+		output.Section(project.Name)
+		projectContainer := project.FindRunningContainer()
+		if len(projectContainer.ID) == 0 {
+			os.Exit(1)
+		}
+
+		mounts, mountsErr := docker.ContainerVolumeMounts(ctx, projectContainer.ID)
+		if mountsErr != nil {
+			output.Error(mountsErr.Error())
+			os.Exit(1)
+		}
+		if len(mounts) == 0 {
+			output.Error("Container has no named volumes")
+			os.Exit(1)
+		}
+
+		snapshot, snapshotErr := config.NewSnapshot(args[0], projectFlag)
+		if snapshotErr != nil {
+			output.Error(snapshotErr.Error())
+			os.Exit(1)
+		}
+		snapshot.Volumes = mounts
+
+		if branch, commit, headErr := git.Head(project.RootPath()); headErr == nil {
+			snapshot.Branch, snapshot.Commit = branch, commit
+		} else {
+			output.Warning(fmt.Sprintf("No branch: %s", headErr.Error()))
+		}
+
+		// Asked while the database still runs
+		if project.Snapshot != nil && len(project.Snapshot.MigrationVersionCmd) > 0 {
+			exitCode, execOutput, execErr := docker.ContainerExec(ctx, projectContainer.ID, project.Snapshot.MigrationVersionCmd)
+			if execErr == nil && exitCode == 0 {
+				snapshot.MigrationVersion = strings.TrimSpace(execOutput)
+			} else if execErr != nil {
+				output.Warning(fmt.Sprintf("No migration version: %s", execErr.Error()))
+			} else {
+				output.Warning(fmt.Sprintf("No migration version, exit code %d", exitCode))
+			}
+		}
+
+		if stopErr := docker.StopContainer(ctx, projectContainer.ID); stopErr != nil {
+			output.Error(stopErr.Error())
+			_ = config.RemoveSnapshot(snapshot.Name)
+			os.Exit(1)
+		}
+		output.Ok("Stopped")
+
+		saveErr := saveVolumes(snapshot)
+		if saveErr == nil {
+			saveErr = snapshot.Save()
+		}
+		if saveErr != nil {
+			output.Error(saveErr.Error())
+			_ = config.RemoveSnapshot(snapshot.Name)
+		} else {
+			output.Ok(fmt.Sprintf("Saved snapshot %s (%s)", snapshot.Name, units.HumanSize(float64(snapshot.Size))))
+		}
+
+		// Back to how it was
+		if startErr := docker.StartContainer(ctx, projectContainer.ID); startErr != nil {
+			output.Error(startErr.Error())
+			os.Exit(1)
+		}
+		util.WaitProjectUp(project)
+
+		if saveErr != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var restore = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Restore the volumes of a snapshot, then restart its project and dependents",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Snapshot")
+
+		ctx := context.Background()
+
+		snapshot, snapshotErr := config.LoadSnapshot(args[0])
+		if snapshotErr != nil {
+			output.Error(snapshotErr.Error())
+			os.Exit(1)
+		}
+
+		project := config.GetProjectByKey(snapshot.Project)
+		if project.Name == "" {
+			output.Error(fmt.Sprintf("Unknown project %s", snapshot.Project))
+			os.Exit(1)
+		}
+
+		output.Section(project.Name)
+		if branch, _, headErr := git.Head(project.RootPath()); headErr == nil && snapshot.Branch != "" && branch != snapshot.Branch {
+			output.Warning(fmt.Sprintf("Snapshot was taken on %s, the repo is on %s", snapshot.Branch, branch))
+		}
+
+		projectContainer := project.FindRunningContainer()
+		if len(projectContainer.ID) > 0 {
+			if stopErr := docker.StopContainer(ctx, projectContainer.ID); stopErr != nil {
+				output.Error(stopErr.Error())
+				os.Exit(1)
+			}
+			output.Ok("Stopped")
+		}
+
+		for _, volume := range snapshot.Volumes {
+			archive, openErr := os.Open(snapshot.VolumeArchivePath(volume.Name))
+			if openErr != nil {
+				output.Error(openErr.Error())
+				os.Exit(1)
+			}
+
+			restoreErr := docker.RestoreVolume(ctx, volume.Name, archive)
+			_ = archive.Close()
+			if restoreErr != nil {
+				output.Error(fmt.Sprintf("%s: %s", volume.Name, restoreErr.Error()))
+				os.Exit(1)
+			}
+			output.Ok(fmt.Sprintf("Restored volume %s", volume.Name))
+		}
+
+		if len(projectContainer.ID) > 0 {
+			if startErr := docker.StartContainer(ctx, projectContainer.ID); startErr != nil {
+				output.Error(startErr.Error())
+				os.Exit(1)
+			}
+			output.Ok("Started")
+			if !util.WaitProjectUp(project) {
+				os.Exit(1)
+			}
+		} else if !util.StartProject(project) {
+			os.Exit(1)
+		}
+
+		for _, dependentKey := range config.RunDependents(snapshot.Project) {
+			dependent := config.GetProjectByKey(dependentKey)
+			output.Section(dependent.Name)
+			util.RestartProject(dependent)
+		}
+	},
+}
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Snapshots")
+
+		snapshots, snapshotsErr := config.Snapshots()
+		if snapshotsErr != nil {
+			output.Error(snapshotsErr.Error())
+			os.Exit(1)
+		}
+
+		if projectFlag != "" {
+			projectSnapshots := []config.Snapshot{}
+			for _, snapshot := range snapshots {
+				if snapshot.Project == projectFlag {
+					projectSnapshots = append(projectSnapshots, snapshot)
+				}
+			}
+			snapshots = projectSnapshots
+		}
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(snapshots); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(snapshots) == 0 {
+			output.Plain("No snapshots, save one with: pld snapshot save <name> -p <project>")
+			return
+		}
+
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Name\tProject\tBranch\tMigration\tDate\tSize\n")
+		for _, snapshot := range snapshots {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", snapshot.Name, snapshot.Project, snapshot.Branch,
+				snapshot.MigrationVersion, snapshot.Date.Format("2006-01-02 15:04"), units.HumanSize(float64(snapshot.Size)))
+		}
+		_ = w.Flush()
+
+		output.Plain(out.String())
+	},
+}
+
+var rm = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a snapshot",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output.Title("Snapshot")
+
+		if removeErr := config.RemoveSnapshot(args[0]); removeErr != nil {
+			output.Error(removeErr.Error())
+			os.Exit(1)
+		}
+
+		output.Ok(fmt.Sprintf("Removed snapshot %s", args[0]))
+	},
+}
+
+// saveVolumes archives every volume of the snapshot into its folder
+func saveVolumes(snapshot config.Snapshot) error {
+	for _, volume := range snapshot.Volumes {
+		archive, createErr := os.Create(snapshot.VolumeArchivePath(volume.Name))
+		if createErr != nil {
+			return createErr
+		}
+
+		saveErr := docker.SaveVolume(context.Background(), volume.Name, archive)
+		closeErr := archive.Close()
+		if saveErr != nil {
+			return fmt.Errorf("%s: %s", volume.Name, saveErr.Error())
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+
+		output.Ok(fmt.Sprintf("Saved volume %s (%s)", volume.Name, volume.Destination))
+	}
+
+	return nil
+}
+
+func init() {
+	save.Flags().StringVarP(&projectFlag, "project", "p", "", "project")
+	_ = save.MarkFlagRequired("project")
+	list.Flags().StringVarP(&projectFlag, "project", "p", "", "only snapshots of this project")
+
+	Command.AddCommand(save)
+	Command.AddCommand(restore)
+	Command.AddCommand(list)
+	Command.AddCommand(rm)
+}
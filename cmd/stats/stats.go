@@ -0,0 +1,230 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ContainerListing is the usage of one project container as listed by `stats`
+type ContainerListing struct {
+	Project   string   `json:"project"`
+	Container string   `json:"container"`
+	ID        string   `json:"id"`
+	Groups    []string `json:"groups"`
+	docker.ContainerUsage
+}
+
+// GroupListing is the usage of every running container of a group added up
+type GroupListing struct {
+	Group      string `json:"group"`
+	Containers int    `json:"containers"`
+	docker.ContainerUsage
+}
+
+// StatsListing is one sample of every project container
+type StatsListing struct {
+	Containers []ContainerListing `json:"containers"`
+	Groups     []GroupListing     `json:"groups"`
+	Total      GroupListing       `json:"total"`
+	NotRunning []string           `json:"not_running"`
+}
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+var liveFlag bool
+var intervalFlag time.Duration
+
+var Command = &cobra.Command{
+	Use:   "stats",
+	Short: "Resource usage of project containers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		projectsToSample, projectsErr := util.ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		if projectsErr != nil {
+			output.Warning(projectsErr.Error())
+			return
+		}
+
+		for {
+			listing, sampleErr := sample(projectsToSample)
+			if sampleErr != nil {
+				output.Error(sampleErr.Error())
+				os.Exit(1)
+			}
+
+			if output.JSONMode() {
+				if jsonErr := output.JSON(listing); jsonErr != nil {
+					output.Error(jsonErr.Error())
+					os.Exit(1)
+				}
+			} else {
+				// Redraw in place
+				if liveFlag {
+					fmt.Print("\033[H\033[2J")
+				}
+				display(listing)
+			}
+
+			if !liveFlag {
+				return
+			}
+			time.Sleep(intervalFlag)
+		}
+	},
+}
+
+// sample reads the stats of every running container of the projects, side by side
+func sample(projects map[string]config.Project) (StatsListing, error) {
+	listing := StatsListing{Containers: []ContainerListing{}, Groups: []GroupListing{}, NotRunning: []string{}}
+
+	containers, containerErr := docker.Containers(context.Background())
+	if containerErr != nil {
+		return listing, containerErr
+	}
+
+	projectKeys := make([]string, 0, len(projects))
+	for projectKey := range projects {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	for _, projectKey := range projectKeys {
+		project := projects[projectKey]
+		matches, matcherErr := project.ContainerMatcher()
+		if matcherErr != nil {
+			return listing, fmt.Errorf("%s: %s", projectKey, matcherErr.Error())
+		}
+
+		running := false
+		for _, container := range containers {
+			if matches(container) {
+				listing.Containers = append(listing.Containers, ContainerListing{
+					Project:   projectKey,
+					Container: docker.ContainerName(container),
+					ID:        container.ID,
+					Groups:    project.Groups,
+				})
+				running = true
+			}
+		}
+		if !running {
+			listing.NotRunning = append(listing.NotRunning, projectKey)
+		}
+	}
+
+	var wg sync.WaitGroup
+	statsErrs := make([]error, len(listing.Containers))
+	for containerIndex := range listing.Containers {
+		wg.Add(1)
+		go func(containerIndex int) {
+			defer wg.Done()
+			containerListing := &listing.Containers[containerIndex]
+			containerListing.ContainerUsage, statsErrs[containerIndex] = docker.ContainerStats(context.Background(), containerListing.ID)
+		}(containerIndex)
+	}
+	wg.Wait()
+
+	// Containers stopping while sampled count as not running, the others are still listed
+	sampled := []ContainerListing{}
+	listedProjects := map[string]bool{}
+	for containerIndex, containerListing := range listing.Containers {
+		if statsErrs[containerIndex] == nil {
+			sampled = append(sampled, containerListing)
+			listedProjects[containerListing.Project] = true
+		}
+	}
+	for containerIndex, containerListing := range listing.Containers {
+		if statsErrs[containerIndex] != nil && !listedProjects[containerListing.Project] {
+			listing.NotRunning = append(listing.NotRunning, containerListing.Project)
+			listedProjects[containerListing.Project] = true
+		}
+	}
+	sort.Strings(listing.NotRunning)
+	listing.Containers = sampled
+
+	// Totals per group and overall
+	groups := map[string]*GroupListing{}
+	listing.Total.Group = "total"
+	for _, containerListing := range listing.Containers {
+		for _, group := range containerListing.Groups {
+			if _, exists := groups[group]; !exists {
+				groups[group] = &GroupListing{Group: group}
+			}
+			groups[group].Containers++
+			groups[group].Add(containerListing.ContainerUsage)
+		}
+		listing.Total.Containers++
+		listing.Total.Add(containerListing.ContainerUsage)
+	}
+	for _, group := range groups {
+		listing.Groups = append(listing.Groups, *group)
+	}
+	sort.Slice(listing.Groups, func(i, j int) bool {
+		return listing.Groups[i].Group < listing.Groups[j].Group
+	})
+
+	return listing, nil
+}
+
+func display(listing StatsListing) {
+	output.Title("Stats")
+
+	output.Section("Containers")
+	if len(listing.Containers) == 0 {
+		output.Plain("No running containers")
+	} else {
+		out := strings.Builder{}
+		w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Project\tContainer\tCPU\tMemory\tMemory %%\tNet I/O\tBlock I/O\n")
+		for _, containerListing := range listing.Containers {
+			usage := containerListing.ContainerUsage
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%.2f%%\t%s / %s\t%.2f%%\t%s\t%s\n",
+				containerListing.Project, containerListing.Container, usage.CPUPercent,
+				units.BytesSize(float64(usage.MemoryUsage)), units.BytesSize(float64(usage.MemoryLimit)), usage.MemoryPercent,
+				ioPair(usage.NetworkRx, usage.NetworkTx), ioPair(usage.BlockRead, usage.BlockWrite))
+		}
+		_ = w.Flush()
+		output.Plain(out.String())
+	}
+
+	output.Section("Groups")
+	out := strings.Builder{}
+	w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Group\tContainers\tCPU\tMemory\tNet I/O\tBlock I/O\n")
+	for _, group := range append(listing.Groups, listing.Total) {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%s\t%s\t%s\n",
+			group.Group, group.Containers, group.CPUPercent, units.BytesSize(float64(group.MemoryUsage)),
+			ioPair(group.NetworkRx, group.NetworkTx), ioPair(group.BlockRead, group.BlockWrite))
+	}
+	_ = w.Flush()
+	output.Plain(out.String())
+
+	if len(listing.NotRunning) > 0 {
+		output.Warning(fmt.Sprintf("Not running: %s", strings.Join(listing.NotRunning, ", ")))
+	}
+}
+
+// ioPair formats a received / sent byte pair the way docker stats does
+func ioPair(in, out uint64) string {
+	return fmt.Sprintf("%s / %s", units.HumanSizeWithPrecision(float64(in), 3), units.HumanSizeWithPrecision(float64(out), 3))
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+	Command.Flags().BoolVarP(&liveFlag, "live", "l", false, "refresh until interrupted")
+	Command.Flags().DurationVar(&intervalFlag, "interval", 2*time.Second, "time between refreshes with --live")
+}
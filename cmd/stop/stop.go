@@ -0,0 +1,53 @@
+package stop
+
+import (
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"sort"
+)
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+
+var Command = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Stop")
+
+		projectsToStop, projectsErr := util.ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		if projectsErr != nil {
+			output.Warning(projectsErr.Error())
+			return
+		}
+
+		projectKeys := []string{}
+		for projectKey := range projectsToStop {
+			projectKeys = append(projectKeys, projectKey)
+		}
+		sort.Strings(projectKeys)
+
+		util.WithGlobalHooks(config.HookPreStop, "", config.PhaseStop, func() bool {
+			stopped := true
+			for _, projectKey := range projectKeys {
+				project := projectsToStop[projectKey]
+				output.Section(project.Name)
+
+				if !util.StopProject(project) {
+					stopped = false
+				}
+			}
+
+			return stopped
+		})
+	},
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+
+var Command = &cobra.Command{
+	Use:   "test",
+	Short: "Run the tests of projects side by side and merge their results",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Test")
+
+		projectsToTest, projectsErr := util.ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		if projectsErr != nil {
+			output.Warning(projectsErr.Error())
+			return
+		}
+
+		projectKeys := []string{}
+		for projectKey, project := range projectsToTest {
+			if len(project.TestCmd) == 0 {
+				output.Warning(fmt.Sprintf("%s has no test_cmd, skipped", projectKey))
+				continue
+			}
+			projectKeys = append(projectKeys, projectKey)
+		}
+		sort.Strings(projectKeys)
+		if len(projectKeys) == 0 {
+			output.Warning("No projects to test")
+			return
+		}
+
+		if pruneErr := config.PruneTestReports(); pruneErr != nil {
+			output.Warning(fmt.Sprintf("Old reports not removed: %s", pruneErr.Error()))
+		}
+
+		report, reportErr := config.NewTestReport()
+		if reportErr != nil {
+			output.Error(reportErr.Error())
+			os.Exit(1)
+		}
+
+		// Projects are tested side by side, as many at once as the parallelism setting allows
+		output.Section("Projects")
+		output.Plain(fmt.Sprintf("Testing %d projects, %d at a time", len(projectKeys), config.Config.Parallelism))
+		queue := make(chan string, len(projectKeys))
+		for _, projectKey := range projectKeys {
+			queue <- projectKey
+		}
+		close(queue)
+
+		results := make(chan config.TestResult)
+		for worker := 0; worker < config.Config.Parallelism && worker < len(projectKeys); worker++ {
+			go func() {
+				for projectKey := range queue {
+					results <- testProject(projectKey, projectsToTest[projectKey], &report)
+				}
+			}()
+		}
+
+		for range projectKeys {
+			result := <-results
+			if result.Passed {
+				output.Ok(fmt.Sprintf("%s passed in %s", result.Project, result.Duration().Round(time.Millisecond)))
+			} else {
+				output.Error(fmt.Sprintf("%s failed in %s: %s", result.Project, result.Duration().Round(time.Millisecond), result.Error))
+				output.Plain(fmt.Sprintf("Output: %s", result.Log))
+			}
+			report.AddResult(result)
+		}
+
+		if saveErr := report.Save(); saveErr != nil {
+			output.Error(saveErr.Error())
+			os.Exit(1)
+		}
+
+		if output.JSONMode() {
+			if jsonErr := output.JSON(report); jsonErr != nil {
+				output.Error(jsonErr.Error())
+				os.Exit(1)
+			}
+		} else {
+			display(report)
+		}
+
+		if !report.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// testProject runs the test commands of a project in sequence, writing their output to its log,
+// then collects the JUnit files they wrote
+func testProject(projectKey string, project config.Project, report *config.TestReport) config.TestResult {
+	started := time.Now()
+	result := config.TestResult{Project: projectKey, Log: report.LogPath(projectKey), Reports: []string{}}
+
+	runErr := runTestCommands(project, result.Log)
+	result.Passed = runErr == nil
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+
+	reportFiles, reportsErr := project.TestReportFiles(started)
+	if reportsErr != nil {
+		result.Warnings = append(result.Warnings, reportsErr.Error())
+	}
+	result.Reports = append(result.Reports, reportFiles...)
+	result.Seconds = time.Since(started).Seconds()
+
+	return result
+}
+
+func runTestCommands(project config.Project, logPath string) error {
+	shellCmds, prepareErr := project.PhasePrepare(config.PhaseTest)
+	if prepareErr != nil {
+		return prepareErr
+	}
+
+	logFile, createErr := os.Create(logPath)
+	if createErr != nil {
+		return createErr
+	}
+	defer logFile.Close()
+
+	for _, shellCmd := range shellCmds {
+		_, _ = fmt.Fprintf(logFile, "$ cd %s && %s\n", shellCmd.Dir, shellCmd.String())
+		shellCmd.Stdout = logFile
+		shellCmd.Stderr = logFile
+
+		if cmdErr := shellCmd.Run(); cmdErr != nil {
+			if exitErr, ok := cmdErr.(*exec.ExitError); ok {
+				return fmt.Errorf("%s exited with status %d", shellCmd.String(), exitErr.ExitCode())
+			}
+			return cmdErr
+		}
+	}
+
+	return nil
+}
+
+func display(report config.TestReport) {
+	output.Section("Results")
+
+	out := strings.Builder{}
+	w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Project\tStatus\tDuration\tTests\tFailures\tErrors\tSkipped\n")
+	for _, result := range report.Results {
+		status := "passed"
+		if !result.Passed {
+			status = "failed"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", result.Project, status, result.Duration().Round(time.Millisecond),
+			result.Tests, result.Failures, result.Errors, result.Skipped)
+	}
+	_ = w.Flush()
+	output.Plain(out.String())
+
+	for _, result := range report.Results {
+		for _, warning := range result.Warnings {
+			output.Warning(fmt.Sprintf("%s: %s", result.Project, warning))
+		}
+	}
+
+	output.Section("Report")
+	output.Plain(report.JUnit)
+	if report.Passed {
+		output.Ok("All projects passed")
+	} else {
+		output.Error("Some projects failed")
+	}
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+)
+
+// BuildProject builds a project between its pre_build and post_build hooks, by its compose service
+// or its build commands. Failures run the on_failure hook.
+func BuildProject(project config.Project) bool {
+	return withHooks(project, config.HookPreBuild, config.HookPostBuild, config.PhaseBuild, func() bool {
+		if project.IsCompose() {
+			return BuildComposeService(project)
+		}
+
+		shellCmds, prepareErr := project.BuildPrepare()
+		if prepareErr != nil {
+			output.Error(prepareErr.Error())
+			return false
+		}
+
+		if len(shellCmds) == 0 {
+			output.Plain("No build commands defined")
+		}
+
+		return RunCommands("Build Command Output", shellCmds)
+	})
+}
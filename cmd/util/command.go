@@ -0,0 +1,84 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/output"
+	"os/exec"
+	"sync"
+)
+
+// RunCommands runs shell commands in sequence, streaming their output, and reports whether every
+// one of them exited 0
+func RunCommands(title string, shellCmds []*exec.Cmd) bool {
+	commandsOk := true
+	for _, shellCmd := range shellCmds {
+
+		output.Plain(fmt.Sprintf("Command: %s", shellCmd.String()))
+		output.Plain(fmt.Sprintf("Path: %s", shellCmd.Dir))
+
+		// Get stdout and stderr pipes
+		stderr, _ := shellCmd.StderrPipe()
+		stdout, _ := shellCmd.StdoutPipe()
+
+		// A command that cannot start fails the sequence like one that exits non-zero
+		if startErr := shellCmd.Start(); startErr != nil {
+			output.Error(startErr.Error())
+			commandsOk = false
+			break
+		}
+
+		// Create output writer channels
+		outputWriter := make(chan string)
+		closeSignal := make(chan bool, 1)
+		finished := make(chan bool, 1)
+
+		// Create output writer coroutine
+		go output.FifoOutput(title, 6, outputWriter, closeSignal, finished)
+
+		// Add STDERR writer coroutine
+		var readers sync.WaitGroup
+		readers.Add(2)
+		go func() {
+			defer readers.Done()
+			scanner := bufio.NewScanner(stderr)
+			scanner.Split(bufio.ScanLines)
+			for scanner.Scan() {
+				m := scanner.Text()
+				outputWriter <- m
+			}
+		}()
+
+		// Add STDOUT writer coroutine
+		go func() {
+			defer readers.Done()
+			scanner := bufio.NewScanner(stdout)
+			scanner.Split(bufio.ScanLines)
+			for scanner.Scan() {
+				m := scanner.Text()
+				outputWriter <- m
+			}
+		}()
+
+		// Wait for the output to be read, then for command to exit
+		readers.Wait()
+		cmdErr := shellCmd.Wait()
+
+		// Send signal to coroutine to clear output
+		closeSignal <- true
+
+		// Block until writer coroutine finished
+		<-finished
+
+		if cmdErr != nil {
+			commandsOk = false
+			if exiterr, ok := cmdErr.(*exec.ExitError); ok {
+				output.Error(fmt.Sprintf("Exit Status: %d", exiterr.ExitCode()))
+			}
+		} else {
+			output.Ok("Done")
+		}
+	}
+
+	return commandsOk
+}
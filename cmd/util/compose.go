@@ -0,0 +1,120 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+)
+
+// RunWithProgress runs an action, streaming its progress lines
+func RunWithProgress(title string, action func(progress chan<- string) error) error {
+
+	// Create output writer channels
+	outputWriter := make(chan string)
+	closeSignal := make(chan bool, 1)
+	finished := make(chan bool, 1)
+
+	// Create output writer coroutine
+	go output.FifoOutput(title, 6, outputWriter, closeSignal, finished)
+
+	actionErr := action(outputWriter)
+
+	// Send signal to coroutine to clear output and block until it finished
+	closeSignal <- true
+	<-finished
+
+	return actionErr
+}
+
+// BuildComposeService builds the image of a compose project
+func BuildComposeService(project config.Project) bool {
+	ref, refErr := project.ServiceRef()
+	if refErr != nil {
+		output.Error(refErr.Error())
+		return false
+	}
+
+	output.Plain(fmt.Sprintf("Service: %s/%s", ref.Project, ref.Service))
+	output.Plain(fmt.Sprintf("File: %s", ref.File.Path))
+
+	buildErr := RunWithProgress("Build Output", func(progress chan<- string) error {
+		return docker.BuildService(context.Background(), ref, progress)
+	})
+	if buildErr != nil {
+		output.Error(buildErr.Error())
+		return false
+	}
+
+	output.Ok("Done")
+	return true
+}
+
+// StartComposeService creates and starts the container of a compose project
+func StartComposeService(project config.Project) bool {
+	ref, refErr := project.ServiceRef()
+	if refErr != nil {
+		output.Error(refErr.Error())
+		return false
+	}
+
+	output.Plain(fmt.Sprintf("Service: %s/%s", ref.Project, ref.Service))
+	output.Plain(fmt.Sprintf("File: %s", ref.File.Path))
+
+	var containerID string
+	upErr := RunWithProgress("Start Output", func(progress chan<- string) error {
+		var startErr error
+		containerID, startErr = docker.UpService(context.Background(), ref, progress)
+		return startErr
+	})
+	if upErr != nil {
+		output.Error(upErr.Error())
+		return false
+	}
+
+	output.Ok(fmt.Sprintf("Started container %s", containerID[:10]))
+	return true
+}
+
+// StopProject stops the container of a project after its pre_stop hook, by its compose service
+// when it has one. Failures run the on_failure hook.
+func StopProject(project config.Project) bool {
+	return withHooks(project, config.HookPreStop, "", config.PhaseStop, func() bool {
+		return stopProject(project)
+	})
+}
+
+func stopProject(project config.Project) bool {
+	if project.IsCompose() {
+		ref, refErr := project.ServiceRef()
+		if refErr != nil {
+			output.Error(refErr.Error())
+			return false
+		}
+
+		stopErr := RunWithProgress("Stop Output", func(progress chan<- string) error {
+			return docker.StopService(context.Background(), ref, progress)
+		})
+		if stopErr != nil {
+			output.Error(stopErr.Error())
+			return false
+		}
+
+		output.Ok("Stopped")
+		return true
+	}
+
+	projectContainer := project.FindRunningContainer()
+	if len(projectContainer.ID) == 0 {
+		return false
+	}
+
+	if stopErr := docker.StopContainer(context.Background(), projectContainer.ID); stopErr != nil {
+		output.Error(stopErr.Error())
+		return false
+	}
+
+	output.Ok("Stopped")
+	return true
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"time"
+)
+
+const (
+	healthTimeout = time.Second * 30
+)
+
+// WaitHealthy waits for a container's health check to pass, until it times out, the container exits
+// or a key is pressed
+func WaitHealthy(projectContainer types.Container) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	healthErr := RunWithProgress("Health Check Output", func(progress chan<- string) error {
+		waitResult := make(chan error, 1)
+		status := make(chan bool, 1)
+
+		healthy := output.InputCancelFunc(func(done chan<- bool) {
+			waitErr := docker.WaitHealthy(ctx, projectContainer.ID, progress)
+			waitResult <- waitErr
+			done <- waitErr == nil
+		}, healthTimeout, status)
+
+		if healthy {
+			return nil
+		}
+
+		// Stop waiting, whether it finished or was given up on
+		cancel()
+		select {
+		case waitErr := <-waitResult:
+			if !errors.Is(waitErr, context.Canceled) {
+				return waitErr
+			}
+		default:
+		}
+
+		return errors.New("gave up waiting")
+	})
+
+	if healthErr != nil {
+		output.Error("NOT Healthy: " + healthErr.Error())
+		return false
+	}
+
+	output.Ok("Healthy")
+	return true
+}
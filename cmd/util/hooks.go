@@ -0,0 +1,69 @@
+package util
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"os/exec"
+)
+
+// RunHook runs the commands of a project hook, reporting whether all of them succeeded
+func RunHook(project config.Project, hook, phase string) bool {
+	shellCmds, prepareErr := project.HookPrepare(hook, phase)
+
+	return runHookCommands(hook, shellCmds, prepareErr)
+}
+
+// RunGlobalHook runs the commands of a global hook from the common config
+func RunGlobalHook(hook, phase string) bool {
+	shellCmds, prepareErr := config.GlobalHookPrepare(hook, phase)
+	if prepareErr == nil && len(shellCmds) > 0 {
+		output.Section(fmt.Sprintf("Global %s", hook))
+	}
+
+	return runHookCommands(hook, shellCmds, prepareErr)
+}
+
+func runHookCommands(hook string, shellCmds []*exec.Cmd, prepareErr error) bool {
+	if prepareErr != nil {
+		output.Error(fmt.Sprintf("%s: %s", hook, prepareErr.Error()))
+		return false
+	}
+
+	if len(shellCmds) == 0 {
+		return true
+	}
+
+	output.Plain(fmt.Sprintf("Hook: %s", hook))
+	return RunCommands("Hook Output", shellCmds)
+}
+
+// withHooks runs a phase of a project between its pre and post hooks. When any of them fails the
+// on_failure hook runs.
+func withHooks(project config.Project, preHook, postHook, phase string, action func() bool) bool {
+	succeeded := RunHook(project, preHook, phase) && action()
+	if succeeded && postHook != "" {
+		succeeded = RunHook(project, postHook, phase)
+	}
+
+	if !succeeded {
+		RunHook(project, config.HookOnFailure, phase)
+	}
+
+	return succeeded
+}
+
+// WithGlobalHooks runs a whole invocation between the global pre and post hooks of its phase. When
+// any of them fails the global on_failure hook runs.
+func WithGlobalHooks(preHook, postHook, phase string, action func() bool) bool {
+	succeeded := RunGlobalHook(preHook, phase) && action()
+	if succeeded && postHook != "" {
+		succeeded = RunGlobalHook(postHook, phase)
+	}
+
+	if !succeeded {
+		RunGlobalHook(config.HookOnFailure, phase)
+	}
+
+	return succeeded
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+)
+
+// EnsureProjectNetworks creates the networks a project declares, reporting those that conflict
+func EnsureProjectNetworks(project config.Project) bool {
+	for _, networkName := range project.Networks {
+		created, ensureErr := docker.EnsureNetwork(context.Background(), config.NetworkSpec(networkName))
+		if ensureErr != nil {
+			output.Error(ensureErr.Error())
+			return false
+		}
+
+		if created {
+			output.Ok(fmt.Sprintf("Created network %s", networkName))
+		}
+	}
+
+	return true
+}
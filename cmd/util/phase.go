@@ -0,0 +1,110 @@
+package util
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
+	"sort"
+)
+
+// RunPhase runs a phase on the projects selected by the flags and, unless ignoreDeps is set, on the
+// dependencies the phase follows, in dependency order. Reports whether it succeeded everywhere.
+func RunPhase(phase, groupFlag, projectFlag string, allFlag, ignoreDeps bool) bool {
+	dependencies, dependenciesErr := config.PhaseDependencies(phase, config.ProjectConfigs)
+	if dependenciesErr != nil {
+		output.Warning(dependenciesErr.Error())
+		return false
+	}
+
+	var projectsToRun map[string]config.Project
+	var projectsErr error
+	orderedProjects := []string{}
+
+	if ignoreDeps || dependencies == config.DependenciesNone {
+		projectsToRun, projectsErr = ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		for projectKey := range projectsToRun {
+			orderedProjects = append(orderedProjects, projectKey)
+		}
+		sort.Strings(orderedProjects)
+	} else {
+		projectsToRun, projectsErr = ProjectsFromFlagsWithDeps(groupFlag, projectFlag, allFlag, dependencies)
+		orderedProjects = config.GenerateOrderedSet(projectsToRun, dependencies)
+	}
+
+	if projectsErr != nil {
+		output.Warning(projectsErr.Error())
+		return false
+	}
+
+	preHook, postHook := config.PhaseHooks(phase)
+
+	followDeps := !ignoreDeps && dependencies != config.DependenciesNone
+
+	return WithGlobalHooks(preHook, postHook, phase, func() bool {
+		// Projects that failed or were skipped, blocking the projects depending on them
+		blocked := map[string]bool{}
+		skipped := []string{}
+		for _, projectKey := range orderedProjects {
+			project := config.GetProjectByKey(projectKey)
+			output.Section(project.Name)
+
+			if followDeps {
+				if blocker := blockingDependency(project, dependencies, blocked); blocker != "" {
+					output.Warning(fmt.Sprintf("Skipped, dependency %s did not complete %s", blocker, phase))
+					blocked[projectKey] = true
+					skipped = append(skipped, projectKey)
+					continue
+				}
+			}
+
+			if !RunProjectPhase(project, phase) {
+				blocked[projectKey] = true
+			}
+		}
+
+		if len(skipped) > 0 {
+			output.Section("Skipped")
+			for _, projectKey := range skipped {
+				output.Warning(projectKey)
+			}
+		}
+
+		return len(blocked) == 0
+	})
+}
+
+// blockingDependency is the first dependency of a project that failed or was skipped, if any
+func blockingDependency(project config.Project, dependencies string, blocked map[string]bool) string {
+	for _, dependency := range project.Dependencies(dependencies) {
+		if blocked[dependency] {
+			return dependency
+		}
+	}
+
+	return ""
+}
+
+// RunProjectPhase runs one phase of a project. Build and start are built in, other phases run their
+// commands from the phases map.
+func RunProjectPhase(project config.Project, phase string) bool {
+	switch phase {
+	case config.PhaseBuild:
+		return BuildProject(project)
+	case config.PhaseStart:
+		return StartProject(project)
+	}
+
+	return withHooks(project, "", "", phase, func() bool {
+		shellCmds, prepareErr := project.PhasePrepare(phase)
+		if prepareErr != nil {
+			output.Error(prepareErr.Error())
+			return false
+		}
+
+		if len(shellCmds) == 0 {
+			output.Plain(fmt.Sprintf("No %s commands defined", phase))
+		}
+
+		return RunCommands(fmt.Sprintf("%s Command Output", phase), shellCmds)
+	})
+}
@@ -0,0 +1,227 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PortBinding is what holds a host port: nothing, a container or another process
+type PortBinding struct {
+	Bound       bool   `json:"bound"`
+	Container   string `json:"container,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	Project     string `json:"project,omitempty"`
+	Process     string `json:"process,omitempty"`
+}
+
+// Describe names what holds the port
+func (b PortBinding) Describe() string {
+	switch {
+	case !b.Bound:
+		return "free"
+	case b.Container != "" && b.Project != "":
+		return fmt.Sprintf("container %s (%s)", b.Container, b.Project)
+	case b.Container != "":
+		return fmt.Sprintf("container %s", b.Container)
+	case b.Process != "":
+		return fmt.Sprintf("process %s", b.Process)
+	}
+
+	return "unknown process"
+}
+
+// FindPortBinding checks whether a host port is in use, naming the container publishing it, or
+// otherwise the process listening on it
+func FindPortBinding(port config.Port, containers []types.Container) PortBinding {
+	if container, published := docker.PublishingContainer(containers, port.Port, port.Protocol); published {
+		return PortBinding{
+			Bound:       true,
+			Container:   docker.ContainerName(container),
+			ContainerID: container.ID,
+			Project:     config.ContainerProject(container),
+		}
+	}
+
+	if !portInUse(port) {
+		return PortBinding{}
+	}
+
+	return PortBinding{Bound: true, Process: portProcess(port)}
+}
+
+// CheckProjectPorts makes sure the declared ports of a project are free, or held by the project's
+// own container, naming whatever holds them otherwise
+func CheckProjectPorts(project config.Project) bool {
+	ports, portsErr := project.DeclaredPorts()
+	if portsErr != nil {
+		output.Error(portsErr.Error())
+		return false
+	}
+	if len(ports) == 0 {
+		return true
+	}
+
+	matches, matcherErr := project.ContainerMatcher()
+	if matcherErr != nil {
+		output.Error(matcherErr.Error())
+		return false
+	}
+
+	// Without docker, ports are still checked, only not named after containers
+	containers, _ := docker.Containers(context.Background())
+
+	portsFree := true
+	for _, port := range ports {
+		binding := FindPortBinding(port, containers)
+		if !binding.Bound {
+			continue
+		}
+
+		if ownContainer(binding, containers, matches) {
+			continue
+		}
+
+		output.Error(fmt.Sprintf("Port %s is in use by %s", port, binding.Describe()))
+		portsFree = false
+	}
+
+	return portsFree
+}
+
+// ownContainer reports whether the container holding a port is one of the project's, matched
+// precisely by labels or repo-prefixed names
+func ownContainer(binding PortBinding, containers []types.Container, matches func(types.Container) bool) bool {
+	for _, container := range containers {
+		if container.ID == binding.ContainerID {
+			return matches(container)
+		}
+	}
+
+	return false
+}
+
+// portInUse tries to bind the port, and for tcp also to connect to it, since a listener on
+// localhost alone does not keep every system from binding all interfaces
+func portInUse(port config.Port) bool {
+	address := fmt.Sprintf(":%d", port.Port)
+
+	if port.Protocol == config.PortProtocolUDP {
+		conn, listenErr := net.ListenPacket("udp", address)
+		if listenErr != nil {
+			return errors.Is(listenErr, syscall.EADDRINUSE)
+		}
+		_ = conn.Close()
+		return false
+	}
+
+	listener, listenErr := net.Listen("tcp", address)
+	if listenErr != nil {
+		return errors.Is(listenErr, syscall.EADDRINUSE)
+	}
+	_ = listener.Close()
+
+	conn, dialErr := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port.Port), 200*time.Millisecond)
+	if dialErr != nil {
+		return false
+	}
+	_ = conn.Close()
+
+	return true
+}
+
+// portProcess names the process listening on a port, through lsof where it is installed and /proc
+// on Linux
+func portProcess(port config.Port) string {
+	if _, lookErr := exec.LookPath("lsof"); lookErr == nil {
+		args := []string{"-nP", fmt.Sprintf("-i%s:%d", strings.ToUpper(port.Protocol), port.Port), "-Fpc"}
+		if port.Protocol == config.PortProtocolTCP {
+			args = append(args, "-sTCP:LISTEN")
+		}
+
+		// Lines of p<pid> and c<command>
+		lsofOutput, _ := exec.Command("lsof", args...).Output()
+		pid, command := "", ""
+		for _, line := range strings.Split(string(lsofOutput), "\n") {
+			if strings.HasPrefix(line, "p") && pid == "" {
+				pid = line[1:]
+			} else if strings.HasPrefix(line, "c") && command == "" {
+				command = line[1:]
+			}
+		}
+		if pid != "" {
+			return fmt.Sprintf("%s (pid %s)", command, pid)
+		}
+	}
+
+	return procPortProcess(port)
+}
+
+// procPortProcess finds the socket of a port in /proc/net, then the process holding that socket
+func procPortProcess(port config.Port) string {
+	// Sockets listening for tcp, bound for udp
+	state := "0A"
+	if port.Protocol == config.PortProtocolUDP {
+		state = "07"
+	}
+
+	inodes := map[string]bool{}
+	for _, table := range []string{port.Protocol, port.Protocol + "6"} {
+		tableFile, openErr := os.Open(filepath.Join("/proc/net", table))
+		if openErr != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(tableFile)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != state {
+				continue
+			}
+
+			localAddress := strings.Split(fields[1], ":")
+			localPort, parseErr := strconv.ParseInt(localAddress[len(localAddress)-1], 16, 32)
+			if parseErr == nil && int(localPort) == port.Port {
+				inodes[fields[9]] = true
+			}
+		}
+		_ = tableFile.Close()
+	}
+
+	if len(inodes) == 0 {
+		return ""
+	}
+
+	processDirs, _ := filepath.Glob("/proc/[0-9]*")
+	for _, processDir := range processDirs {
+		fds, _ := ioutil.ReadDir(filepath.Join(processDir, "fd"))
+		for _, fd := range fds {
+			link, linkErr := os.Readlink(filepath.Join(processDir, "fd", fd.Name()))
+			if linkErr != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+
+			if inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+				command, _ := ioutil.ReadFile(filepath.Join(processDir, "comm"))
+				return fmt.Sprintf("%s (pid %s)", strings.TrimSpace(string(command)), filepath.Base(processDir))
+			}
+		}
+	}
+
+	// Sockets of other users' processes cannot be traced without root
+	return "a process of another user"
+}
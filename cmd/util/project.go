@@ -16,6 +16,8 @@ func ProjectsFromFlags(groupFlag, projectFlag string, allFlag bool) (map[string]
 		projects = config.GetProjectsByGroup(groupFlag)
 	} else if projectFlag != "" {
 		projects[projectFlag] = config.GetProjectByKey(projectFlag)
+	} else if config.Config.DefaultGroup != "" {
+		projects = config.GetProjectsByGroup(config.Config.DefaultGroup)
 	}
 
 	if len(projects) == 0 {
@@ -0,0 +1,198 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WaitReady runs the readiness probes of a project side by side until all of them pass or one
+// times out
+func WaitReady(project config.Project, projectContainer types.Container) bool {
+	readiness, expandErr := project.ExpandedReadiness()
+	if expandErr != nil {
+		output.Error(expandErr.Error())
+		return false
+	}
+
+	var probeErrs []string
+	readyErr := RunWithProgress("Readiness Output", func(progress chan<- string) error {
+		probes := readinessProbes(readiness, projectContainer.ID)
+
+		var wg sync.WaitGroup
+		var errsLock sync.Mutex
+		for _, probe := range probes {
+			wg.Add(1)
+			go func(probe func(chan<- string) error) {
+				defer wg.Done()
+				if probeErr := probe(progress); probeErr != nil {
+					errsLock.Lock()
+					probeErrs = append(probeErrs, probeErr.Error())
+					errsLock.Unlock()
+				}
+			}(probe)
+		}
+		wg.Wait()
+
+		if len(probeErrs) > 0 {
+			return errors.New("NOT Ready")
+		}
+		return nil
+	})
+
+	for _, probeErr := range probeErrs {
+		output.Error(probeErr)
+	}
+
+	if readyErr != nil {
+		output.Error(readyErr.Error())
+		return false
+	}
+
+	output.Ok("Ready")
+	return true
+}
+
+func readinessProbes(readiness config.Readiness, containerID string) []func(chan<- string) error {
+	probes := []func(chan<- string) error{}
+
+	if readiness.TCP != nil {
+		probe := readiness.TCP
+		probes = append(probes, func(progress chan<- string) error {
+			return pollProbe(fmt.Sprintf("tcp %s", probe.Address()), probe.ProbeTiming, progress, func(interval time.Duration) error {
+				connection, dialErr := net.DialTimeout("tcp", probe.Address(), interval)
+				if dialErr != nil {
+					return dialErr
+				}
+				return connection.Close()
+			})
+		})
+	}
+
+	if readiness.HTTP != nil {
+		probe := readiness.HTTP
+		probes = append(probes, func(progress chan<- string) error {
+			return pollProbe(fmt.Sprintf("http %s", probe.URL), probe.ProbeTiming, progress, func(interval time.Duration) error {
+				client := http.Client{Timeout: interval}
+				response, getErr := client.Get(probe.URL)
+				if getErr != nil {
+					return getErr
+				}
+				_ = response.Body.Close()
+				if response.StatusCode != probe.ExpectedStatus() {
+					return fmt.Errorf("status %d", response.StatusCode)
+				}
+				return nil
+			})
+		})
+	}
+
+	if readiness.Exec != nil {
+		probe := readiness.Exec
+		probes = append(probes, func(progress chan<- string) error {
+			name := fmt.Sprintf("exec %s", strings.Join(probe.Command, " "))
+			if containerID == "" {
+				return fmt.Errorf("%s: no container to run in", name)
+			}
+			return pollProbe(name, probe.ProbeTiming, progress, func(interval time.Duration) error {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				defer cancel()
+				exitCode, execOutput, execErr := docker.ContainerExec(ctx, containerID, probe.Command)
+				if execErr != nil {
+					return execErr
+				}
+				if exitCode != 0 {
+					return fmt.Errorf("exit %d %s", exitCode, strings.TrimSpace(execOutput))
+				}
+				return nil
+			})
+		})
+	}
+
+	if readiness.Log != nil {
+		probe := readiness.Log
+		probes = append(probes, func(progress chan<- string) error {
+			return waitForLogLine(probe, containerID, progress)
+		})
+	}
+
+	return probes
+}
+
+// pollProbe retries a check every interval until it passes or the timeout is reached
+func pollProbe(name string, timing config.ProbeTiming, progress chan<- string, check func(time.Duration) error) error {
+	interval, timeout, timingErr := timing.Durations()
+	if timingErr != nil {
+		return fmt.Errorf("%s: %s", name, timingErr.Error())
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		checkErr := check(interval)
+		if checkErr == nil {
+			progress <- fmt.Sprintf("%s: ready", name)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s: not ready after %s: %s", name, timeout, checkErr.Error())
+		}
+
+		progress <- fmt.Sprintf("%s: %s", name, checkErr.Error())
+		time.Sleep(interval)
+	}
+}
+
+// waitForLogLine follows the container log until a line matches or the timeout is reached
+func waitForLogLine(probe *config.LogProbe, containerID string, progress chan<- string) error {
+	name := fmt.Sprintf("log /%s/", probe.Pattern)
+	if containerID == "" {
+		return fmt.Errorf("%s: no container to follow", name)
+	}
+
+	_, timeout, timingErr := probe.Durations()
+	if timingErr != nil {
+		return fmt.Errorf("%s: %s", name, timingErr.Error())
+	}
+
+	pattern, patternErr := regexp.Compile(probe.Pattern)
+	if patternErr != nil {
+		return fmt.Errorf("%s: %s", name, patternErr.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lines := make(chan string)
+	logErr := make(chan error, 1)
+	go func() {
+		logErr <- docker.ContainerLogLines(ctx, containerID, lines)
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			if pattern.MatchString(line) {
+				progress <- fmt.Sprintf("%s: ready", name)
+				return nil
+			}
+		case followErr := <-logErr:
+			if followErr == nil {
+				return fmt.Errorf("%s: container log ended without a match", name)
+			}
+			return fmt.Errorf("%s: %s", name, followErr.Error())
+		case <-ctx.Done():
+			return fmt.Errorf("%s: not ready after %s", name, timeout)
+		}
+	}
+}
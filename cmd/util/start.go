@@ -0,0 +1,77 @@
+package util
+
+import (
+	"context"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+)
+
+// StartProject runs a project between its pre_start and post_start hooks, by its compose service or
+// its run commands, then waits for it to be ready and healthy. Failures run the on_failure hook.
+func StartProject(project config.Project) bool {
+	return withHooks(project, config.HookPreStart, config.HookPostStart, config.PhaseStart, func() bool {
+		if !EnsureProjectNetworks(project) || !CheckProjectPorts(project) {
+			return false
+		}
+
+		if project.IsCompose() {
+			return StartComposeService(project) && WaitProjectUp(project)
+		}
+
+		shellCmds, prepareErr := project.RunPrepare()
+		if prepareErr != nil {
+			output.Error(prepareErr.Error())
+			return false
+		}
+
+		if len(shellCmds) == 0 {
+			output.Plain("No run commands defined")
+		}
+
+		commandsOk := RunCommands("Run Command Output", shellCmds)
+
+		return commandsOk && WaitProjectUp(project)
+	})
+}
+
+// WaitProjectUp finds the project's container and waits on its readiness probes and health check
+func WaitProjectUp(project config.Project) bool {
+	projectContainer := project.FindRunningContainer()
+
+	// Readiness probes hold back dependents of services without a docker health check
+	ready := true
+	if project.Readiness != nil {
+		ready = WaitReady(project, projectContainer)
+	}
+
+	if len(projectContainer.ID) == 0 {
+		return ready
+	}
+
+	if !docker.ContainerHasHealthCheck(context.Background(), &projectContainer) {
+		if project.Readiness == nil {
+			output.Warning("Container has no health check")
+		}
+		return ready
+	}
+
+	return WaitHealthy(projectContainer) && ready
+}
+
+// RestartProject restarts the running container of a project and waits for it to come back up,
+// starting the project instead when it has no running container
+func RestartProject(project config.Project) bool {
+	projectContainer := project.FindRunningContainer()
+	if len(projectContainer.ID) == 0 {
+		return StartProject(project)
+	}
+
+	if restartErr := docker.RestartContainer(context.Background(), projectContainer.ID); restartErr != nil {
+		output.Error(restartErr.Error())
+		return false
+	}
+	output.Ok("Restarted")
+
+	return WaitProjectUp(project)
+}
@@ -0,0 +1,222 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/cmd/util"
+	"github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/docker"
+	"github.com/poloniex/polo-local-dev/output"
+	"github.com/spf13/cobra"
+	"sort"
+	"time"
+)
+
+const (
+	// Restarts per project before pld watch leaves a crash-looping project alone
+	crashRestartLimit = 5
+
+	// A project staying up this long after a restart starts counting its restarts from zero again
+	crashResetAfter = 10 * time.Minute
+)
+
+var groupFlag string
+var projectFlag string
+var allFlag bool
+var restartFlag bool
+var notifyFlag bool
+
+// watchedProject is a project and the way to recognise its containers
+type watchedProject struct {
+	key     string
+	project config.Project
+	matches func(types.Container) bool
+}
+
+// restartHistory counts the restarts of a crash-looping project
+type restartHistory struct {
+	count int
+	last  time.Time
+}
+
+var Command = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch project containers and react to crashes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		output.Title("Watch")
+
+		projectsToWatch, projectsErr := util.ProjectsFromFlags(groupFlag, projectFlag, allFlag)
+		if projectsErr != nil {
+			output.Warning(projectsErr.Error())
+			return
+		}
+
+		watched := []watchedProject{}
+		for _, projectKey := range sortedProjectKeys(projectsToWatch) {
+			project := projectsToWatch[projectKey]
+			matches, matcherErr := project.ContainerMatcher()
+			if matcherErr != nil {
+				output.Error(fmt.Sprintf("%s: %s", projectKey, matcherErr.Error()))
+				continue
+			}
+			watched = append(watched, watchedProject{key: projectKey, project: project, matches: matches})
+		}
+
+		// Current state
+		output.Section("Containers")
+		containers, containerErr := docker.Containers(context.Background())
+		if containerErr != nil {
+			output.Error(containerErr.Error())
+			return
+		}
+		for _, watchedProject := range watched {
+			running := false
+			for _, container := range containers {
+				if watchedProject.matches(container) {
+					output.Ok(fmt.Sprintf("%s: %s", watchedProject.key, container.Status))
+					running = true
+				}
+			}
+			if !running {
+				output.Warning(fmt.Sprintf("%s: not running", watchedProject.key))
+			}
+		}
+
+		// Changes as they happen
+		output.Section("Events")
+		messages, eventErrs := docker.ContainerEvents(context.Background())
+
+		stopping := map[string]bool{}
+		restarts := map[string]*restartHistory{}
+		for {
+			select {
+			case eventErr := <-eventErrs:
+				output.Error(eventErr.Error())
+				return
+
+			case message := <-messages:
+				container := docker.EventContainer(message)
+
+				var projectKey string
+				var project config.Project
+				for _, watchedProject := range watched {
+					if watchedProject.matches(container) {
+						projectKey, project = watchedProject.key, watchedProject.project
+						break
+					}
+				}
+				if projectKey == "" {
+					continue
+				}
+
+				action, detail := docker.EventAction(message)
+				timestamp := time.Unix(0, message.TimeNano).Format("15:04:05")
+
+				switch action {
+				case "start":
+					delete(stopping, container.ID)
+					output.Ok(fmt.Sprintf("%s %s: started", timestamp, projectKey))
+
+				case "health_status":
+					if detail == types.Healthy {
+						output.Ok(fmt.Sprintf("%s %s: %s", timestamp, projectKey, detail))
+					} else {
+						output.Warning(fmt.Sprintf("%s %s: %s", timestamp, projectKey, detail))
+					}
+
+				// Stopped or killed on purpose, the die that follows is no crash
+				case "kill":
+					stopping[container.ID] = true
+
+				case "stop":
+					output.Warning(fmt.Sprintf("%s %s: stopped", timestamp, projectKey))
+
+				case "oom":
+					output.Error(fmt.Sprintf("%s %s: out of memory", timestamp, projectKey))
+
+				case "destroy":
+					delete(stopping, container.ID)
+
+				case "die":
+					if stopping[container.ID] {
+						continue
+					}
+
+					exitCode := docker.EventExitCode(message)
+					crash := fmt.Sprintf("%s crashed with exit code %d", projectKey, exitCode)
+					output.Error(fmt.Sprintf("%s %s", timestamp, crash))
+					if notifyFlag {
+						output.Notify("pld watch", crash)
+					} else {
+						output.Bell()
+					}
+
+					if restartFlag && project.ShouldRestart(exitCode) {
+						restartCrashed(projectKey, project, container.ID, restarts)
+						output.Section("Events")
+					}
+				}
+			}
+		}
+	},
+}
+
+// restartCrashed starts a crashed project again, then restarts everything that needs it to run
+func restartCrashed(projectKey string, project config.Project, containerID string, restarts map[string]*restartHistory) {
+	restartsItself, inspectErr := docker.ContainerRestartsItself(context.Background(), containerID)
+	if inspectErr != nil {
+		output.Error(inspectErr.Error())
+		return
+	}
+	if restartsItself {
+		output.Plain(fmt.Sprintf("%s is restarted by docker", projectKey))
+		return
+	}
+
+	history, restarted := restarts[projectKey]
+	if !restarted {
+		history = &restartHistory{}
+		restarts[projectKey] = history
+	}
+	if time.Since(history.last) >= crashResetAfter {
+		history.count = 0
+	}
+
+	if history.count >= crashRestartLimit {
+		output.Warning(fmt.Sprintf("%s restarted %d times already, leaving it stopped", projectKey, history.count))
+		return
+	}
+	history.count++
+	history.last = time.Now()
+
+	output.Section(project.Name)
+	if !util.StartProject(project) {
+		output.Error(fmt.Sprintf("%s did not come back up, its dependents are left as they are", projectKey))
+		return
+	}
+
+	for _, dependentKey := range config.RunDependents(projectKey) {
+		dependent := config.GetProjectByKey(dependentKey)
+		output.Section(dependent.Name)
+		util.RestartProject(dependent)
+	}
+}
+
+func sortedProjectKeys(projects map[string]config.Project) []string {
+	projectKeys := make([]string, 0, len(projects))
+	for projectKey := range projects {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	return projectKeys
+}
+
+func init() {
+	util.CommonProjectFlags(Command, &groupFlag, &projectFlag, &allFlag)
+	Command.Flags().BoolVarP(&restartFlag, "restart", "r", false, "restart crashed projects according to their restart policy, then their dependents")
+	Command.Flags().BoolVarP(&notifyFlag, "notify", "n", false, "desktop notification on crashes instead of the terminal bell")
+}
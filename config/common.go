@@ -1,5 +1,14 @@
 package config
 
 type CommonConfig struct {
-	WorkspaceRoot string `json:"workspace_root"`
+	WorkspaceRoot    string            `json:"workspace_root"`
+	Profile          string            `json:"profile,omitempty"`
+	GitProtocol      string            `json:"git_protocol,omitempty"`
+	GithubHost       string            `json:"github_host,omitempty"`
+	Parallelism      int               `json:"parallelism,omitempty"`
+	DefaultGroup     string            `json:"default_group,omitempty"`
+	LogRetentionDays int               `json:"log_retention_days,omitempty"`
+	Sources          []Source          `json:"sources,omitempty"`
+	Variables        map[string]string `json:"variables,omitempty"`
+	Hooks            *Hooks            `json:"hooks,omitempty"`
 }
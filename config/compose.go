@@ -0,0 +1,331 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/docker"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// Folder (inside the config path) holding the last generated version of each imported file
+	importsFolder = "imports"
+)
+
+// ComposeImport is the outcome of importing a compose file
+type ComposeImport struct {
+	File      string
+	Added     []string
+	Updated   []string
+	Unchanged []string
+	Removed   []string
+	Skipped   []string
+}
+
+// IsCompose reports whether the project is built and run from its compose service through the
+// docker API instead of commands
+func (p *Project) IsCompose() bool {
+	return p.Type == ProjectTypeCompose
+}
+
+// ServiceRef locates the compose service of a compose project. The compose file defaults to the
+// one at the project root, the service to the project name and the compose project to the file's.
+func (p *Project) ServiceRef() (docker.ServiceRef, error) {
+	var ref docker.ServiceRef
+
+	composeConfig := ComposeConfig{}
+	if p.Compose != nil {
+		composeConfig = *p.Compose
+	}
+
+	fileTemplate := composeConfig.File
+	if fileTemplate == "" {
+		fileTemplate = "#PROJECT_ROOT#"
+	}
+
+	composePath, expandErr := p.expand(fileTemplate, true)
+	if expandErr != nil {
+		return ref, expandErr
+	}
+
+	composeFile, composeErr := docker.LoadComposeFile(composePath, ActiveProfile.Env)
+	if composeErr != nil {
+		return ref, composeErr
+	}
+
+	ref.File = composeFile
+	ref.Service = composeConfig.Service
+	if ref.Service == "" {
+		ref.Service = p.Name
+	}
+	ref.Project = composeConfig.Project
+	if ref.Project == "" {
+		ref.Project = composeFile.ComposeProjectName()
+	}
+
+	service, exists := composeFile.Services[ref.Service]
+	if !exists {
+		return ref, fmt.Errorf("service %s not found in %s", ref.Service, composeFile.Path)
+	}
+
+	if supportedErr := service.Supported(); supportedErr != nil {
+		return ref, fmt.Errorf("%s: %s", ref.Service, supportedErr.Error())
+	}
+
+	return ref, nil
+}
+
+// composeLocation maps a compose file to the repo it lives in and the path its commands run from
+func composeLocation(composePath string) (string, string, string) {
+	composeDir := filepath.Dir(composePath)
+
+	// Compose finds its default files on its own
+	composeArgs := ""
+	for _, fileName := range docker.ComposeFileNames {
+		if filepath.Base(composePath) == fileName {
+			composeArgs = " "
+			break
+		}
+	}
+	if composeArgs == "" {
+		composeArgs = fmt.Sprintf(" -f %s ", filepath.Base(composePath))
+	}
+
+	relativeDir, relErr := filepath.Rel(Config.WorkspaceRoot, composeDir)
+	if relErr != nil || relativeDir == "." || strings.HasPrefix(relativeDir, "..") {
+		return filepath.Base(composeDir), composeDir + "/", composeArgs
+	}
+
+	repo := strings.SplitN(relativeDir, string(os.PathSeparator), 2)[0]
+
+	return repo, fmt.Sprintf("#WORKSPACE_ROOT#/%s/", filepath.ToSlash(relativeDir)), composeArgs
+}
+
+// importFileName names the user-layer file generated for a compose file
+func importFileName(composePath string) string {
+	composeDir := filepath.Dir(composePath)
+
+	name := filepath.Base(composeDir)
+	if relativeDir, relErr := filepath.Rel(Config.WorkspaceRoot, composeDir); relErr == nil && relativeDir != "." && !strings.HasPrefix(relativeDir, "..") {
+		name = strings.ReplaceAll(filepath.ToSlash(relativeDir), "/", "-")
+	}
+
+	return fmt.Sprintf("compose-%s.project.json", name)
+}
+
+// generateComposeProjects turns every compose service into a project. Services already defined
+// by another config file are skipped, and dependencies on them point at the existing project.
+func generateComposeProjects(composeFile docker.ComposeFile, fileName, group string) (map[string]interface{}, []string, error) {
+	repo, commandPath, composeArgs := composeLocation(composeFile.Path)
+
+	generated := map[string]interface{}{}
+	skipped := []string{}
+
+	// Service name to project key, starting with the services other files already define
+	serviceKeys := map[string]string{}
+	definedElsewhere := map[string]bool{}
+	for _, service := range composeFile.ServiceNames() {
+		for projectKey, project := range ProjectConfigs {
+			if Provenances[projectKey].File == fileName {
+				continue
+			}
+			if projectKey == service || (project.Name == service && project.GetRepoName() == repo) {
+				serviceKeys[service] = projectKey
+				definedElsewhere[service] = true
+				skipped = append(skipped, fmt.Sprintf("%s (defined as %s)", service, projectKey))
+				break
+			}
+		}
+	}
+
+	for _, service := range composeFile.ServiceNames() {
+		if !definedElsewhere[service] {
+			serviceKeys[service] = service
+		}
+	}
+
+	for _, service := range composeFile.ServiceNames() {
+		if definedElsewhere[service] {
+			continue
+		}
+		composeService := composeFile.Services[service]
+
+		project := Project{
+			Name: service,
+			Repo: repo,
+		}
+
+		if group != "" {
+			project.Groups = []string{group}
+		}
+
+		for _, dependency := range composeService.DependsOn {
+			if dependencyKey, known := serviceKeys[dependency]; known {
+				project.DependsOn.Run = append(project.DependsOn.Run, dependencyKey)
+			} else {
+				project.DependsOn.Run = append(project.DependsOn.Run, dependency)
+			}
+		}
+
+		if composeService.Build != nil {
+			project.BuildCmd = []ShellCommand{{
+				Command: fmt.Sprintf("docker-compose%sbuild #NAME#", composeArgs),
+				Path:    commandPath,
+			}}
+		}
+
+		project.RunCmd = []ShellCommand{{
+			Command: fmt.Sprintf("docker-compose%sup -d --no-deps #NAME#", composeArgs),
+			Path:    commandPath,
+		}}
+
+		raw, rawErr := toRaw(project)
+		if rawErr != nil {
+			return nil, nil, fmt.Errorf("%s: %s", service, rawErr.Error())
+		}
+		if len(project.DependsOn.Run) == 0 {
+			delete(raw, "depends_on")
+		}
+		generated[service] = map[string]interface{}(raw)
+	}
+
+	return generated, skipped, nil
+}
+
+// readRawFile reads a JSON file into an untyped map, an absent file being empty
+func readRawFile(path string) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+
+	fileBytes, fileReadErr := ioutil.ReadFile(path)
+	if fileReadErr != nil {
+		if os.IsNotExist(fileReadErr) {
+			return raw, nil
+		}
+		return nil, fileReadErr
+	}
+
+	if parseErr := json.Unmarshal(fileBytes, &raw); parseErr != nil {
+		return nil, fmt.Errorf("%s: %s", path, parseErr.Error())
+	}
+
+	return raw, nil
+}
+
+func writeRawFile(path string, raw map[string]interface{}) error {
+	rawJson, jsonErr := json.MarshalIndent(raw, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return ioutil.WriteFile(path, rawJson, os.ModePerm)
+}
+
+// ImportCompose generates project configs from a compose file into the user layer. Re-importing
+// updates the generated file, keeping every value that was edited by hand since the last import.
+func ImportCompose(path, group string) (ComposeImport, error) {
+	var result ComposeImport
+
+	composeFile, composeErr := docker.LoadComposeFile(path, nil)
+	if composeErr != nil {
+		return result, composeErr
+	}
+
+	fileName := importFileName(composeFile.Path)
+	filePath := filepath.Join(configDir(), fileName)
+	snapshotPath := filepath.Join(configDir(), importsFolder, fileName)
+	result.File = filePath
+
+	generated, skipped, generateErr := generateComposeProjects(composeFile, fileName, group)
+	if generateErr != nil {
+		return result, generateErr
+	}
+	result.Skipped = skipped
+
+	current, currentErr := readRawFile(filePath)
+	if currentErr != nil {
+		return result, currentErr
+	}
+
+	// Without the generated file there are no edits to keep
+	snapshot := map[string]interface{}{}
+	if len(current) > 0 {
+		var snapshotErr error
+		if snapshot, snapshotErr = readRawFile(snapshotPath); snapshotErr != nil {
+			return result, snapshotErr
+		}
+	}
+
+	merged, _ := mergeThreeWay(snapshot, current, generated).(map[string]interface{})
+	if merged == nil {
+		merged = map[string]interface{}{}
+	}
+
+	for _, projectKey := range sortedRawKeys(generated, current) {
+		_, inCurrent := current[projectKey]
+		_, inMerged := merged[projectKey]
+		switch {
+		case !inCurrent && inMerged:
+			result.Added = append(result.Added, projectKey)
+		case inCurrent && !inMerged:
+			result.Removed = append(result.Removed, projectKey)
+		case inCurrent && !reflect.DeepEqual(current[projectKey], merged[projectKey]):
+			result.Updated = append(result.Updated, projectKey)
+		case inCurrent:
+			result.Unchanged = append(result.Unchanged, projectKey)
+		}
+	}
+
+	// Never write a file that would not load
+	mergedJson, jsonErr := json.Marshal(merged)
+	if jsonErr != nil {
+		return result, jsonErr
+	}
+	if _, parseErr := parseProjectFile(mergedJson); parseErr != nil {
+		return result, parseErr
+	}
+
+	if mkdirErr := os.MkdirAll(filepath.Dir(snapshotPath), os.ModePerm); mkdirErr != nil {
+		return result, mkdirErr
+	}
+
+	if writeErr := writeRawFile(filePath, merged); writeErr != nil {
+		return result, writeErr
+	}
+
+	if writeErr := writeRawFile(snapshotPath, generated); writeErr != nil {
+		return result, writeErr
+	}
+
+	// Imported projects are user-created, whatever dist adds later
+	provenance, provenanceLoadErr := loadProvenance()
+	if provenanceLoadErr != nil {
+		return result, provenanceLoadErr
+	}
+	for projectKey := range merged {
+		provenance[projectKey] = Provenance{Source: SourceUser, File: fileName}
+	}
+
+	return result, saveProvenance(provenance)
+}
+
+func sortedRawKeys(maps ...map[string]interface{}) []string {
+	keySet := map[string]bool{}
+	for _, values := range maps {
+		for key := range values {
+			keySet[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -6,13 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"github.com/manifoldco/promptui"
+	"github.com/poloniex/polo-local-dev/git"
 	"github.com/poloniex/polo-local-dev/output"
+	"golang.org/x/term"
 	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
@@ -20,12 +23,19 @@ var (
 	//go:embed dist
 	distEmbed embed.FS
 
-	// Default path for local configs
+	// Default path for local configs, PLD_HOME takes precedence
 	configPath = "~/.pld/"
 
+	// Environment overrides
+	envHome          = "PLD_HOME"
+	envWorkspaceRoot = "PLD_WORKSPACE_ROOT"
+
 	// For validation of paths
 	pathRegex, _ = regexp.Compile("^(/[^/ ]*)+/?$")
 
+	// For validation of project keys, which double as file names
+	projectKeyRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
 	// ProjectConfigs post-installation and resolution
 	ProjectConfigs = map[string]Project{}
 
@@ -49,63 +59,212 @@ func absolutePath(path string) string {
 	return path
 }
 
-func init() {
+// configDir is the folder holding local configs
+func configDir() string {
+	if home := os.Getenv(envHome); home != "" {
+		return absolutePath(home)
+	}
+
+	return absolutePath(configPath)
+}
 
-	output.Title("Config Check")
+func ensureConfigDir() error {
+	if _, err := os.Stat(configDir()); errors.Is(err, os.ErrNotExist) {
+		return os.MkdirAll(configDir(), os.ModePerm)
+	}
+
+	return nil
+}
+
+// IsInteractive reports whether the user can be prompted
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Load installs and resolves the common and project configs. Only failures that leave pld unusable
+// are returned, everything else is reported and skipped.
+func Load() error {
 
 	// Ensure ~/.pld/ exists
-	configAbsolutePath := absolutePath(configPath)
-	if _, err := os.Stat(configAbsolutePath); errors.Is(err, os.ErrNotExist) {
-		mkdirErr := os.Mkdir(configAbsolutePath, os.ModePerm)
-		if mkdirErr != nil {
-			output.Error(mkdirErr.Error())
-			os.Exit(1)
-		}
+	if mkdirErr := ensureConfigDir(); mkdirErr != nil {
+		return mkdirErr
 	}
 
 	// Load common config
 	var commonConfigErr error
 	Config, commonConfigErr = loadCommonConfig()
 	if commonConfigErr != nil {
-		output.Error(commonConfigErr.Error())
+		return commonConfigErr
+	}
+
+	// Layer the active profile, which every title shows from here on
+	profileErr := applyProfile(&Config)
+	if profileErr != nil && profileOverride != "" {
+		return profileErr
+	}
+	if ActiveProfileName != "" {
+		output.SetTitleContext(fmt.Sprintf("profile: %s", ActiveProfileName))
+	}
+
+	output.Title("Config Check")
+
+	if profileErr != nil {
+		output.Warning(profileErr.Error())
+	}
+
+	if gitConfigureErr := git.Configure(Config.GithubHost, Config.GitProtocol); gitConfigureErr != nil {
+		return gitConfigureErr
 	}
 
 	// Load dist and local project configs
-	distConfigs, installedConfigs, configLoadErr := loadProjectConfigs()
+	distConfigs, distSources, distLoadErr := loadDistConfigs()
+	if distLoadErr != nil {
+		output.Error(distLoadErr.Error())
+	}
+	distProjectConfigs = distConfigs
+	distProjectSources = distSources
+
+	installedConfigs, installedFiles, configLoadErr := loadInstalledConfigs()
 	if configLoadErr != nil {
 		output.Error(configLoadErr.Error())
 	}
 
+	provenance, provenanceLoadErr := loadProvenance()
+	if provenanceLoadErr != nil {
+		output.Error(provenanceLoadErr.Error())
+	}
+	provenance = resolveProvenance(provenance, distConfigs, installedConfigs, installedFiles)
+
 	// Search for uninstalled configs and install
-	for projectName := range distConfigs {
-		if _, installed := installedConfigs[projectName]; !installed {
-			installErr := installProjectConfig(projectName, distConfigs[projectName])
-			if installErr != nil {
-				output.Error(installErr.Error())
-			} else {
-				output.Ok(fmt.Sprintf("Installing config: %s", projectName))
-			}
+	for _, installErr := range installDistConfigs(distConfigs, installedConfigs, provenance) {
+		output.Error(installErr.Error())
+	}
+
+	// Remove configs no longer in dist, flag the ones edited locally. Skipped when a source failed
+	// to load, as its projects would look removed.
+	if distLoadErr == nil {
+		for _, projectKey := range pruneStaleConfigs(provenance) {
+			output.Ok(fmt.Sprintf("Removing stale config: %s", projectKey))
 		}
 	}
 
-	// TODO: Logic to remove stale/removed config files (e.g. no longer in dist, still in local)
+	if saveErr := saveProvenance(provenance); saveErr != nil {
+		output.Error(saveErr.Error())
+	}
 
 	// Reload local configs
-	_, installedConfigs, configLoadErr = loadProjectConfigs()
+	installedConfigs, installedFiles, configLoadErr = loadInstalledConfigs()
 	if configLoadErr != nil {
 		output.Error(configLoadErr.Error())
 	}
 
+	Provenances = resolveProvenance(provenance, distConfigs, installedConfigs, installedFiles)
+	for projectKey, record := range Provenances {
+		if record.Stale {
+			output.Warning(fmt.Sprintf("%s was removed from dist but is modified locally", projectKey))
+		}
+	}
+
+	// Apply templates, so repo configs and overrides act on the expanded projects
+	installedConfigs, resolveErrs := resolveProjects(installedConfigs)
+	for _, resolveErr := range resolveErrs {
+		output.Error(resolveErr.Error())
+	}
+
+	// Layer project configs discovered in each repo
+	repoConfigs, repoFiles, repoLoadErrs := loadRepoConfigs()
+	for _, repoLoadErr := range repoLoadErrs {
+		output.Error(repoLoadErr.Error())
+	}
+
+	repoConfigs, resolveErrs = resolveProjects(repoConfigs)
+	for _, resolveErr := range resolveErrs {
+		output.Error(resolveErr.Error())
+	}
+
+	for _, conflict := range mergeRepoConfigs(installedConfigs, repoConfigs, repoFiles, Provenances) {
+		output.Warning(conflict)
+	}
+
+	// Apply user overrides on top of installed configs
+	overrides, overridesLoadErr := loadOverrides()
+	if overridesLoadErr != nil {
+		output.Error(overridesLoadErr.Error())
+	}
+
+	installedConfigs, overrideErrs := applyOverrides(installedConfigs, overrides)
+	for _, overrideErr := range overrideErrs {
+		output.Error(overrideErr.Error())
+	}
+
+	for projectKey, projectOverrides := range overrides {
+		if record, exists := Provenances[projectKey]; exists {
+			for _, projectOverride := range projectOverrides {
+				record.Overrides = append(record.Overrides, projectOverride.file)
+			}
+			Provenances[projectKey] = record
+		}
+	}
+
+	for _, migrationWarning := range migrateTestPhases(installedConfigs) {
+		output.Warning(migrationWarning)
+	}
+
 	for projectName, project := range installedConfigs {
 		ProjectConfigs[projectName] = project
 	}
 
+	for projectKey, validationErrs := range ValidateProjects() {
+		for _, validationErr := range validationErrs {
+			output.Error(fmt.Sprintf("%s: %s", projectKey, validationErr.Error()))
+		}
+	}
+
+	for _, networkErr := range ValidateNetworks() {
+		output.Error(networkErr.Error())
+	}
+
 	output.Ok("Config validated")
+
+	return nil
+}
+
+// Init writes the common config with the given workspace root, keeping any other settings
+func Init(workspaceRoot string) error {
+	if mkdirErr := ensureConfigDir(); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	commonConfig, readErr := readCommonConfig()
+	if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+		return readErr
+	}
+
+	if !pathRegex.MatchString(absolutePath(workspaceRoot)) {
+		return fmt.Errorf("invalid workspace root: %s", workspaceRoot)
+	}
+	commonConfig.WorkspaceRoot = absolutePath(workspaceRoot)
+
+	if configInstallErr := installCommonConfig(commonConfig); configInstallErr != nil {
+		return fmt.Errorf("could not write %s: %s", commonConfigPath(), configInstallErr.Error())
+	}
+
+	return nil
 }
 
 func generateCommonConfig() (CommonConfig, error) {
 	commonConfig := CommonConfig{}
 
+	// Environment takes precedence, then the user is asked when possible
+	if workspaceRoot := os.Getenv(envWorkspaceRoot); workspaceRoot != "" {
+		commonConfig.WorkspaceRoot = absolutePath(workspaceRoot)
+		return commonConfig, nil
+	}
+
+	if !IsInteractive() {
+		return commonConfig, fmt.Errorf("pld is not initialized, run `pld init --workspace-root DIR` or set %s", envWorkspaceRoot)
+	}
+
 	workspaceRootPrompt := promptui.Prompt{
 		Label: "Workspace root",
 		Validate: func(input string) error {
@@ -125,18 +284,28 @@ func generateCommonConfig() (CommonConfig, error) {
 	return commonConfig, nil
 }
 
-func loadCommonConfig() (CommonConfig, error) {
+func commonConfigPath() string {
+	return filepath.Join(configDir(), "config.json")
+}
+
+func readCommonConfig() (CommonConfig, error) {
 	var commonConfig CommonConfig
-	if _, err := os.Stat(absolutePath(configPath) + "/config.json"); err == nil {
-		configFile, fileReadErr := ioutil.ReadFile(absolutePath(configPath) + "/config.json")
-		if fileReadErr != nil {
-			return commonConfig, fileReadErr
-		}
 
-		if parseErr := json.Unmarshal(configFile, &commonConfig); parseErr != nil {
-			return commonConfig, parseErr
-		}
-	} else {
+	configFile, fileReadErr := ioutil.ReadFile(commonConfigPath())
+	if fileReadErr != nil {
+		return commonConfig, fileReadErr
+	}
+
+	if parseErr := json.Unmarshal(configFile, &commonConfig); parseErr != nil {
+		return commonConfig, fmt.Errorf("%s: %s", commonConfigPath(), parseErr.Error())
+	}
+
+	return commonConfig, nil
+}
+
+func loadCommonConfig() (CommonConfig, error) {
+	commonConfig, readErr := readCommonConfig()
+	if errors.Is(readErr, os.ErrNotExist) {
 		var commonConfigErr error
 		commonConfig, commonConfigErr = generateCommonConfig()
 		if commonConfigErr != nil {
@@ -144,25 +313,49 @@ func loadCommonConfig() (CommonConfig, error) {
 		}
 
 		if configInstallErr := installCommonConfig(commonConfig); configInstallErr != nil {
-			return commonConfig, commonConfigErr
+			return commonConfig, fmt.Errorf("could not write %s: %s", commonConfigPath(), configInstallErr.Error())
 		}
+	} else if readErr != nil {
+		return commonConfig, readErr
+	}
+
+	if validateErr := validateCommonConfig(commonConfig); validateErr != nil {
+		return commonConfig, fmt.Errorf("%s: %s", commonConfigPath(), validateErr.Error())
+	}
+
+	// Environment overrides and defaults, never persisted
+	if workspaceRoot := os.Getenv(envWorkspaceRoot); workspaceRoot != "" {
+		commonConfig.WorkspaceRoot = absolutePath(workspaceRoot)
 	}
+	applySettingDefaults(&commonConfig)
 
 	return commonConfig, nil
 }
 
 func Reload() {
 
-	d, err := os.Open(absolutePath(configPath))
-	if err != nil {
-		fmt.Println(err)
+	installedConfigs, installedFiles, configLoadErr := loadInstalledConfigs()
+	if configLoadErr != nil {
+		output.Error(configLoadErr.Error())
 		os.Exit(1)
 	}
-	defer func(d *os.File) {
-		_ = d.Close()
-	}(d)
 
-	files, err := d.Readdir(-1)
+	provenance, provenanceLoadErr := loadProvenance()
+	if provenanceLoadErr != nil {
+		output.Error(provenanceLoadErr.Error())
+		os.Exit(1)
+	}
+	provenance = resolveProvenance(provenance, distProjectConfigs, installedConfigs, installedFiles)
+
+	// Group user-created projects by the file they live in
+	userFiles := map[string][]string{}
+	for projectKey, record := range provenance {
+		if record.Source == SourceUser {
+			userFiles[record.File] = append(userFiles[record.File], projectKey)
+		}
+	}
+
+	files, err := ioutil.ReadDir(configDir())
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -171,100 +364,143 @@ func Reload() {
 	for _, file := range files {
 		if file.Mode().IsRegular() {
 			if strings.Contains(file.Name(), ".project.json") {
-				if deleteErr := os.Remove(absolutePath(configPath) + string(os.PathSeparator) + file.Name()); deleteErr != nil {
+
+				// Never delete user-created projects without asking
+				if userProjects, userCreated := userFiles[file.Name()]; userCreated {
+					if !Confirm(fmt.Sprintf("Delete user-created %s (%s)", file.Name(), strings.Join(userProjects, ", "))) {
+						output.Plain(fmt.Sprintf("Keeping %s", file.Name()))
+						continue
+					}
+				}
+
+				if deleteErr := os.Remove(configDir() + string(os.PathSeparator) + file.Name()); deleteErr != nil {
 					output.Error(fmt.Sprintf("could not delete %s", file.Name()))
 					continue
 				}
+
+				for projectKey, record := range provenance {
+					if record.File == file.Name() {
+						delete(provenance, projectKey)
+					}
+				}
 			}
 		}
 	}
 
-	// Load dist and local project configs
-	distConfigs, installedConfigs, _ := loadProjectConfigs()
+	// Load local project configs that survived and reinstall dist
+	installedConfigs, _, configLoadErr = loadInstalledConfigs()
+	if configLoadErr != nil {
+		// Installing over configs that failed to load would overwrite them
+		output.Error(configLoadErr.Error())
+		os.Exit(1)
+	}
+
+	installErrs := installDistConfigs(distProjectConfigs, installedConfigs, provenance)
+	if saveErr := saveProvenance(provenance); saveErr != nil {
+		output.Error(saveErr.Error())
+	}
 
+	if len(installErrs) > 0 {
+		for _, installErr := range installErrs {
+			output.Error(installErr.Error())
+		}
+		os.Exit(1)
+	}
+}
+
+// installDistConfigs installs every dist project that is not installed yet and records its provenance
+func installDistConfigs(distConfigs, installedConfigs map[string]Project, provenance map[string]Provenance) []error {
+	var errs []error
+
+	version := distVersion(distConfigs)
+	installedAt := time.Now()
 	for projectName := range distConfigs {
 		if _, installed := installedConfigs[projectName]; !installed {
 			installErr := installProjectConfig(projectName, distConfigs[projectName])
 			if installErr != nil {
-				output.Error(installErr.Error())
-				os.Exit(1)
+				errs = append(errs, installErr)
+				continue
+			}
+
+			provenance[projectName] = Provenance{
+				Source:      SourceDist,
+				DistSource:  distProjectSources[projectName],
+				File:        installedFileName(projectName),
+				DistHash:    projectHash(distConfigs[projectName]),
+				DistVersion: version,
+				InstalledAt: &installedAt,
 			}
 			output.Ok(fmt.Sprintf("Installing config: %s", projectName))
 		}
 	}
+
+	return errs
 }
 
-func loadProjectConfigs() (map[string]Project, map[string]Project, error) {
+// loadDistConfigs reads every configured source, later sources taking precedence. Sources that fail
+// to load are skipped and reported together.
+func loadDistConfigs() (map[string]Project, map[string]string, error) {
 
 	distConfigs := map[string]Project{}
+	distSources := map[string]string{}
+	var sourceErrs []string
+
+	for _, source := range configuredSources() {
+		sourceConfigs, sourceLoadErr := loadSourceConfigs(source)
+		if sourceLoadErr != nil {
+			sourceErrs = append(sourceErrs, sourceLoadErr.Error())
+			continue
+		}
 
-	// Load embedded configs
-	distFiles, distReadErr := distEmbed.ReadDir("dist")
-	if distReadErr != nil {
-		return nil, nil, distReadErr
+		for projectName, project := range sourceConfigs {
+			distConfigs[projectName] = project
+			distSources[projectName] = source.SourceName()
+		}
 	}
 
-	for _, distFilename := range distFiles {
-
-		// Only parse *.project.json files
-		if strings.Contains(distFilename.Name(), ".project.json") {
-
-			// Read file contents
-			distFile, fileReadErr := distEmbed.ReadFile("dist/" + distFilename.Name())
-			if fileReadErr != nil {
-				return nil, nil, fileReadErr
-			}
+	if len(sourceErrs) > 0 {
+		return distConfigs, distSources, errors.New(strings.Join(sourceErrs, "; "))
+	}
 
-			// Unmarshal into ProjectFile
-			var distConfig ProjectFile
-			if parseErr := json.Unmarshal(distFile, &distConfig); parseErr != nil {
-				return nil, nil, parseErr
-			}
+	return distConfigs, distSources, nil
+}
 
-			// Append to returned set
-			for projectName, project := range distConfig {
-				distConfigs[projectName] = project
-			}
-		}
-	}
+// loadInstalledConfigs reads the installed project configs along with the file each one lives in
+func loadInstalledConfigs() (map[string]Project, map[string]string, error) {
 
 	installedConfigs := map[string]Project{}
+	installedFiles := map[string]string{}
 
-	installedFiles, installFolderReadErr := ioutil.ReadDir(absolutePath(configPath))
+	installedFileList, installFolderReadErr := ioutil.ReadDir(configDir())
 	if installFolderReadErr != nil {
 		return nil, nil, installFolderReadErr
 	}
 
-	for _, installedFile := range installedFiles {
+	for _, installedFile := range installedFileList {
 		if !installedFile.IsDir() && strings.Contains(installedFile.Name(), ".project.json") {
 
-			jsonFile, fileOpenErr := os.Open(absolutePath(configPath) + "/" + installedFile.Name())
-			if fileOpenErr != nil {
-				return nil, nil, fileOpenErr
-			}
-			defer func(jsonFile *os.File) {
-				_ = jsonFile.Close()
-			}(jsonFile)
-
-			fileBytes, fileReadErr := ioutil.ReadAll(jsonFile)
+			fileBytes, fileReadErr := ioutil.ReadFile(configDir() + "/" + installedFile.Name())
 			if fileReadErr != nil {
 				return nil, nil, fileReadErr
 			}
 
-			// Unmarshal into ProjectFile
-			var installedConfig ProjectFile
-			if parseErr := json.Unmarshal(fileBytes, &installedConfig); parseErr != nil {
-				return nil, nil, parseErr
+			// Parse into ProjectFile, templates and group settings
+			installedConfigFile, parseErr := parseProjectFile(fileBytes)
+			if parseErr != nil {
+				return nil, nil, fmt.Errorf("%s: %s", installedFile.Name(), parseErr.Error())
 			}
+			installedConfigFile.register()
+			installedConfig := installedConfigFile.projects
 
 			// Append to returned set
 			for projectName, project := range installedConfig {
 				installedConfigs[projectName] = project
+				installedFiles[projectName] = installedFile.Name()
 			}
 		}
 	}
 
-	return distConfigs, installedConfigs, nil
+	return installedConfigs, installedFiles, nil
 }
 
 func installProjectConfig(name string, project Project) error {
@@ -278,7 +514,7 @@ func installProjectConfig(name string, project Project) error {
 		return jsonErr
 	}
 
-	fileWriteErr := ioutil.WriteFile(fmt.Sprintf("%s/%s.project.json", absolutePath(configPath), name), projectJson, os.ModePerm)
+	fileWriteErr := ioutil.WriteFile(fmt.Sprintf("%s/%s.project.json", configDir(), name), projectJson, os.ModePerm)
 	if fileWriteErr != nil {
 		return fileWriteErr
 	}
@@ -286,6 +522,54 @@ func installProjectConfig(name string, project Project) error {
 	return nil
 }
 
+// AddProject validates a new project and writes it to the user layer
+func AddProject(key string, project Project) (string, error) {
+	if !projectKeyRegex.MatchString(key) {
+		return "", fmt.Errorf("invalid project key: %s", key)
+	}
+
+	if _, exists := ProjectConfigs[key]; exists {
+		return "", fmt.Errorf("project %s already exists", key)
+	}
+
+	if _, exists := distProjectConfigs[key]; exists {
+		return "", fmt.Errorf("project %s exists in dist, use an override instead", key)
+	}
+
+	for _, dependency := range project.Dependencies("") {
+		if _, exists := ProjectConfigs[dependency]; !exists {
+			return "", fmt.Errorf("unknown dependency: %s", dependency)
+		}
+	}
+
+	resolved, resolveErr := resolveProject(project)
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	if validateErrs := resolved.Validate(); len(validateErrs) > 0 {
+		return "", validateErrs[0]
+	}
+
+	if installErr := installProjectConfig(key, project); installErr != nil {
+		return "", installErr
+	}
+
+	// Recorded as user-created so a later dist project of the same key never claims it
+	provenance, provenanceLoadErr := loadProvenance()
+	if provenanceLoadErr != nil {
+		return "", provenanceLoadErr
+	}
+	provenance[key] = Provenance{Source: SourceUser, File: installedFileName(key)}
+	if saveErr := saveProvenance(provenance); saveErr != nil {
+		return "", saveErr
+	}
+
+	ProjectConfigs[key] = resolved
+
+	return filepath.Join(configDir(), installedFileName(key)), nil
+}
+
 func installCommonConfig(commonConfig CommonConfig) error {
 
 	configJson, jsonErr := json.MarshalIndent(&commonConfig, "", "    ")
@@ -293,10 +577,26 @@ func installCommonConfig(commonConfig CommonConfig) error {
 		return jsonErr
 	}
 
-	fileWriteErr := ioutil.WriteFile(fmt.Sprintf("%s/config.json", absolutePath(configPath)), configJson, os.ModePerm)
+	fileWriteErr := ioutil.WriteFile(commonConfigPath(), configJson, os.ModePerm)
 	if fileWriteErr != nil {
 		return fileWriteErr
 	}
 
 	return nil
 }
+
+// Confirm asks a yes/no question, never confirming without a terminal
+func Confirm(label string) bool {
+	if !IsInteractive() {
+		return false
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	_, promptErr := confirmPrompt.Run()
+
+	return promptErr == nil
+}
@@ -0,0 +1,8 @@
+package config
+
+// DevConfig holds the files pld dev watches for changes, as globs in .gitignore syntax relative to
+// the project root. Without include globs every file git does not ignore is watched.
+type DevConfig struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+const (
+	// Hooks, run around the phases of a project, or of a whole invocation for global hooks
+	HookPreBuild  = "pre_build"
+	HookPostBuild = "post_build"
+	HookPreStart  = "pre_start"
+	HookPostStart = "post_start"
+	HookOnFailure = "on_failure"
+	HookPreStop   = "pre_stop"
+
+	// Phases hooks run in, passed to hook commands as PLD_PHASE
+	PhaseBuild = "build"
+	PhaseStart = "start"
+	PhaseStop  = "stop"
+	PhaseTest  = "test"
+)
+
+// Hooks are commands run around build, start and stop, in the same format as build_cmd and run_cmd
+type Hooks struct {
+	PreBuild  []ShellCommand `json:"pre_build,omitempty"`
+	PostBuild []ShellCommand `json:"post_build,omitempty"`
+	PreStart  []ShellCommand `json:"pre_start,omitempty"`
+	PostStart []ShellCommand `json:"post_start,omitempty"`
+	OnFailure []ShellCommand `json:"on_failure,omitempty"`
+	PreStop   []ShellCommand `json:"pre_stop,omitempty"`
+}
+
+// Commands are the commands of a hook by name
+func (h *Hooks) Commands(hook string) []ShellCommand {
+	switch hook {
+	case HookPreBuild:
+		return h.PreBuild
+	case HookPostBuild:
+		return h.PostBuild
+	case HookPreStart:
+		return h.PreStart
+	case HookPostStart:
+		return h.PostStart
+	case HookOnFailure:
+		return h.OnFailure
+	case HookPreStop:
+		return h.PreStop
+	}
+
+	return nil
+}
+
+// PhaseHooks are the hooks run before and after a phase, none for phases other than build and start
+func PhaseHooks(phase string) (string, string) {
+	switch phase {
+	case PhaseBuild:
+		return HookPreBuild, HookPostBuild
+	case PhaseStart:
+		return HookPreStart, HookPostStart
+	}
+
+	return "", ""
+}
+
+// all lists every hook command, for validation
+func (h *Hooks) all() []ShellCommand {
+	commands := []ShellCommand{}
+	for _, hook := range []string{HookPreBuild, HookPostBuild, HookPreStart, HookPostStart, HookOnFailure, HookPreStop} {
+		commands = append(commands, h.Commands(hook)...)
+	}
+
+	return commands
+}
+
+// resolveRepoPaths makes relative paths of every hook command relative to the repo
+func (h Hooks) resolveRepoPaths(repoPath string) Hooks {
+	return Hooks{
+		PreBuild:  resolveRepoPaths(repoPath, h.PreBuild),
+		PostBuild: resolveRepoPaths(repoPath, h.PostBuild),
+		PreStart:  resolveRepoPaths(repoPath, h.PreStart),
+		PostStart: resolveRepoPaths(repoPath, h.PostStart),
+		OnFailure: resolveRepoPaths(repoPath, h.OnFailure),
+		PreStop:   resolveRepoPaths(repoPath, h.PreStop),
+	}
+}
+
+// HookPrepare prepares the commands of a project hook. They get PLD_HOOK, PLD_PHASE and PLD_PROJECT
+// in their environment.
+func (p *Project) HookPrepare(hook, phase string) ([]*exec.Cmd, error) {
+	cmds, prepareErr := p.prepareCommands(p.Hooks.Commands(hook))
+	if prepareErr != nil {
+		return nil, prepareErr
+	}
+
+	for _, cmd := range cmds {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("PLD_HOOK=%s", hook), fmt.Sprintf("PLD_PHASE=%s", phase), fmt.Sprintf("PLD_PROJECT=%s", p.Name))
+	}
+
+	return cmds, nil
+}
+
+// GlobalHookPrepare prepares the commands of a global hook from the common config. Placeholders
+// resolve without a project.
+func GlobalHookPrepare(hook, phase string) ([]*exec.Cmd, error) {
+	global := Project{}
+	if Config.Hooks != nil {
+		global.Hooks = *Config.Hooks
+	}
+
+	return global.HookPrepare(hook, phase)
+}
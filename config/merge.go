@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	listAppend  = "$append"
+	listRemove  = "$remove"
+	listReplace = "$replace"
+)
+
+// rawProject is the untyped form of a Project, used while layering configs
+type rawProject map[string]interface{}
+
+func toRaw(project Project) (rawProject, error) {
+	projectJson, jsonErr := json.Marshal(&project)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	raw := rawProject{}
+	if parseErr := json.Unmarshal(projectJson, &raw); parseErr != nil {
+		return nil, parseErr
+	}
+
+	return raw, nil
+}
+
+func fromRaw(raw rawProject) (Project, error) {
+	var project Project
+
+	projectJson, jsonErr := json.Marshal(raw)
+	if jsonErr != nil {
+		return project, jsonErr
+	}
+
+	if parseErr := json.Unmarshal(projectJson, &project); parseErr != nil {
+		return project, parseErr
+	}
+
+	return project, nil
+}
+
+// mergeValues deep-merges overlay on top of base. Objects are merged key by key, a null removes
+// the key, scalars and plain lists replace, and list directives ($append, $remove, $replace) edit
+// the base list in place.
+func mergeValues(base, overlay interface{}) (interface{}, error) {
+	overlayMap, overlayIsMap := overlay.(map[string]interface{})
+	if !overlayIsMap {
+		return overlay, nil
+	}
+
+	if isListDirective(overlayMap) {
+		baseList, baseIsList := base.([]interface{})
+		if !baseIsList && base != nil {
+			return nil, fmt.Errorf("list directive applied to non-list value")
+		}
+		return applyListDirective(baseList, overlayMap)
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	merged := map[string]interface{}{}
+	if baseIsMap {
+		for key, value := range baseMap {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range overlayMap {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		mergedValue, mergeErr := mergeValues(merged[key], value)
+		if mergeErr != nil {
+			return nil, fmt.Errorf("%s: %s", key, mergeErr.Error())
+		}
+		merged[key] = mergedValue
+	}
+
+	return merged, nil
+}
+
+func mergeRaw(base, overlay rawProject) (rawProject, error) {
+	merged, mergeErr := mergeValues(map[string]interface{}(base), map[string]interface{}(overlay))
+	if mergeErr != nil {
+		return nil, mergeErr
+	}
+
+	return merged.(map[string]interface{}), nil
+}
+
+func isListDirective(value map[string]interface{}) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	for key := range value {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func applyListDirective(base []interface{}, directive map[string]interface{}) ([]interface{}, error) {
+	for key := range directive {
+		if key != listAppend && key != listRemove && key != listReplace {
+			return nil, fmt.Errorf("unknown list directive %s", key)
+		}
+	}
+
+	result := append([]interface{}{}, base...)
+
+	if replacement, exists := directive[listReplace]; exists {
+		replacementList, ok := replacement.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects a list", listReplace)
+		}
+		result = append([]interface{}{}, replacementList...)
+	}
+
+	if removals, exists := directive[listRemove]; exists {
+		removalList, ok := removals.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects a list", listRemove)
+		}
+		kept := []interface{}{}
+		for _, item := range result {
+			if !listContains(removalList, item) {
+				kept = append(kept, item)
+			}
+		}
+		result = kept
+	}
+
+	if additions, exists := directive[listAppend]; exists {
+		additionList, ok := additions.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects a list", listAppend)
+		}
+		for _, item := range additionList {
+			if !listContains(result, item) {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func listContains(list []interface{}, item interface{}) bool {
+	for _, listItem := range list {
+		if reflect.DeepEqual(listItem, item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flattenRaw maps dotted field paths to JSON-encoded leaf values. Lists are kept whole.
+func flattenRaw(prefix string, value interface{}, flat map[string]string) {
+	if valueMap, isMap := value.(map[string]interface{}); isMap {
+		for key, child := range valueMap {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenRaw(path, child, flat)
+		}
+		return
+	}
+
+	encoded, _ := json.Marshal(value)
+	flat[prefix] = string(encoded)
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// mergeThreeWay carries changes between two generated versions into a file that may have been edited
+// by hand. Values the user left as generated take the new generated value, values the user changed
+// are kept. Nil stands for an absent value.
+func mergeThreeWay(base, current, next interface{}) interface{} {
+	if reflect.DeepEqual(base, current) {
+		return next
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	currentMap, currentIsMap := current.(map[string]interface{})
+	nextMap, nextIsMap := next.(map[string]interface{})
+	if !currentIsMap || (base != nil && !baseIsMap) || (next != nil && !nextIsMap) {
+		return current
+	}
+
+	merged := map[string]interface{}{}
+	keys := map[string]bool{}
+	for _, values := range []map[string]interface{}{baseMap, currentMap, nextMap} {
+		for key := range values {
+			keys[key] = true
+		}
+	}
+
+	for key := range keys {
+		if value := mergeThreeWay(baseMap[key], currentMap[key], nextMap[key]); value != nil {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeJSON turns a JSON literal into the untyped values the merge functions work on, nil for an
+// empty string
+func decodeJSON(t *testing.T, literal string) interface{} {
+	t.Helper()
+
+	if literal == "" {
+		return nil
+	}
+
+	var value interface{}
+	if parseErr := json.Unmarshal([]byte(literal), &value); parseErr != nil {
+		t.Fatalf("%s: %s", literal, parseErr)
+	}
+
+	return value
+}
+
+func TestMergeValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+		wantErr bool
+	}{
+		{name: "scalar replaces", base: `{"a": 1}`, overlay: `{"a": 2}`, want: `{"a": 2}`},
+		{name: "new key added", base: `{"a": 1}`, overlay: `{"b": 2}`, want: `{"a": 1, "b": 2}`},
+		{name: "null removes", base: `{"a": 1, "b": 2}`, overlay: `{"b": null}`, want: `{"a": 1}`},
+		{name: "objects merge deep", base: `{"o": {"a": 1, "b": 2}}`, overlay: `{"o": {"b": 3}}`, want: `{"o": {"a": 1, "b": 3}}`},
+		{name: "plain list replaces", base: `{"l": [1, 2]}`, overlay: `{"l": [3]}`, want: `{"l": [3]}`},
+		{name: "append", base: `{"l": ["a", "b"]}`, overlay: `{"l": {"$append": ["c"]}}`, want: `{"l": ["a", "b", "c"]}`},
+		{name: "append skips present items", base: `{"l": ["a", "b"]}`, overlay: `{"l": {"$append": ["b", "c"]}}`, want: `{"l": ["a", "b", "c"]}`},
+		{name: "append to missing list", base: `{}`, overlay: `{"l": {"$append": ["a"]}}`, want: `{"l": ["a"]}`},
+		{name: "remove", base: `{"l": ["a", "b", "c"]}`, overlay: `{"l": {"$remove": ["b", "x"]}}`, want: `{"l": ["a", "c"]}`},
+		{name: "remove objects", base: `{"l": [{"c": "x"}, {"c": "y"}]}`, overlay: `{"l": {"$remove": [{"c": "x"}]}}`, want: `{"l": [{"c": "y"}]}`},
+		{name: "replace", base: `{"l": ["a", "b"]}`, overlay: `{"l": {"$replace": ["z"]}}`, want: `{"l": ["z"]}`},
+		{name: "replace then remove then append", base: `{"l": ["a"]}`, overlay: `{"l": {"$replace": ["x", "y"], "$remove": ["x"], "$append": ["z"]}}`, want: `{"l": ["y", "z"]}`},
+		{name: "nested directive", base: `{"d": {"run": ["a", "b"]}}`, overlay: `{"d": {"run": {"$remove": ["a"]}}}`, want: `{"d": {"run": ["b"]}}`},
+		{name: "unknown directive", base: `{"l": ["a"]}`, overlay: `{"l": {"$insert": ["b"]}}`, wantErr: true},
+		{name: "directive on a scalar", base: `{"l": "a"}`, overlay: `{"l": {"$append": ["b"]}}`, wantErr: true},
+		{name: "directive without a list", base: `{"l": ["a"]}`, overlay: `{"l": {"$append": "b"}}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, mergeErr := mergeValues(decodeJSON(t, test.base), decodeJSON(t, test.overlay))
+			if test.wantErr {
+				if mergeErr == nil {
+					t.Fatalf("merged to %v, want an error", merged)
+				}
+				return
+			}
+			if mergeErr != nil {
+				t.Fatal(mergeErr)
+			}
+			if want := decodeJSON(t, test.want); !reflect.DeepEqual(merged, want) {
+				t.Fatalf("merged to %v, want %v", merged, want)
+			}
+		})
+	}
+}
+
+func TestMergeValuesLeavesBaseUntouched(t *testing.T) {
+	base := decodeJSON(t, `{"l": ["a", "b"], "o": {"k": 1}}`)
+	if _, mergeErr := mergeValues(base, decodeJSON(t, `{"l": {"$remove": ["a"]}, "o": {"k": 2}}`)); mergeErr != nil {
+		t.Fatal(mergeErr)
+	}
+
+	if want := decodeJSON(t, `{"l": ["a", "b"], "o": {"k": 1}}`); !reflect.DeepEqual(base, want) {
+		t.Fatalf("base changed to %v", base)
+	}
+}
+
+func TestMergeThreeWay(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		current string
+		next    string
+		want    string
+	}{
+		{name: "untouched takes next", base: `{"a": 1}`, current: `{"a": 1}`, next: `{"a": 2}`, want: `{"a": 2}`},
+		{name: "edited value kept", base: `{"a": 1}`, current: `{"a": 5}`, next: `{"a": 2}`, want: `{"a": 5}`},
+		{name: "edits and updates side by side", base: `{"a": 1, "b": 1}`, current: `{"a": 5, "b": 1}`, next: `{"a": 2, "b": 2}`, want: `{"a": 5, "b": 2}`},
+		{name: "added by user kept", base: `{"a": 1}`, current: `{"a": 1, "u": true}`, next: `{"a": 1}`, want: `{"a": 1, "u": true}`},
+		{name: "removed by user stays removed", base: `{"a": 1, "b": 1}`, current: `{"a": 1}`, next: `{"a": 1, "b": 2}`, want: `{"a": 1}`},
+		{name: "removed from generated dropped", base: `{"a": 1, "b": 1}`, current: `{"a": 1, "b": 1}`, next: `{"a": 1}`, want: `{"a": 1}`},
+		{name: "new generated key added", base: `{"a": 1}`, current: `{"a": 5}`, next: `{"a": 1, "n": 1}`, want: `{"a": 5, "n": 1}`},
+		{name: "nested", base: `{"o": {"x": 1, "y": 1}}`, current: `{"o": {"x": 9, "y": 1}}`, next: `{"o": {"x": 2, "y": 2}}`, want: `{"o": {"x": 9, "y": 2}}`},
+		{name: "edited list kept whole", base: `{"l": [1]}`, current: `{"l": [1, 2]}`, next: `{"l": [3]}`, want: `{"l": [1, 2]}`},
+		{name: "no previous import", base: ``, current: `{"a": 5}`, next: `{"a": 1}`, want: `{"a": 5}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeThreeWay(decodeJSON(t, test.base), decodeJSON(t, test.current), decodeJSON(t, test.next))
+			if want := decodeJSON(t, test.want); !reflect.DeepEqual(merged, want) {
+				t.Fatalf("merged to %v, want %v", merged, want)
+			}
+		})
+	}
+}
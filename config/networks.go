@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/docker"
+	"net"
+	"sort"
+)
+
+var (
+	// NetworkConfigs are the network definitions collected from every project file
+	NetworkConfigs = map[string]NetworkConfig{}
+)
+
+// NetworkConfig defines a docker network projects can share. Networks projects use without a
+// definition are plain bridge networks.
+type NetworkConfig struct {
+	Driver     string            `json:"driver,omitempty"`
+	Subnet     string            `json:"subnet,omitempty"`
+	Gateway    string            `json:"gateway,omitempty"`
+	Internal   bool              `json:"internal,omitempty"`
+	Attachable bool              `json:"attachable,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Validate checks the subnet and gateway of a network definition
+func (n *NetworkConfig) Validate() error {
+	if n.Subnet != "" {
+		if _, _, parseErr := net.ParseCIDR(n.Subnet); parseErr != nil {
+			return fmt.Errorf("invalid subnet %s", n.Subnet)
+		}
+	}
+
+	if n.Gateway != "" && net.ParseIP(n.Gateway) == nil {
+		return fmt.Errorf("invalid gateway %s", n.Gateway)
+	}
+
+	if n.Gateway != "" && n.Subnet == "" {
+		return fmt.Errorf("gateway %s needs a subnet", n.Gateway)
+	}
+
+	return nil
+}
+
+// NetworkSpec is the spec pld creates a network from
+func NetworkSpec(name string) docker.NetworkSpec {
+	networkConfig := NetworkConfigs[name]
+
+	return docker.NetworkSpec{
+		Name:       name,
+		Driver:     networkConfig.Driver,
+		Subnet:     networkConfig.Subnet,
+		Gateway:    networkConfig.Gateway,
+		Internal:   networkConfig.Internal,
+		Attachable: networkConfig.Attachable,
+		Labels:     networkConfig.Labels,
+	}
+}
+
+// NetworkUsers maps every declared network to the projects using it, including definitions no
+// project uses yet
+func NetworkUsers() map[string][]string {
+	users := map[string][]string{}
+	for networkName := range NetworkConfigs {
+		users[networkName] = []string{}
+	}
+
+	for projectKey, project := range ProjectConfigs {
+		for _, networkName := range project.Networks {
+			users[networkName] = append(users[networkName], projectKey)
+		}
+	}
+
+	for networkName := range users {
+		sort.Strings(users[networkName])
+	}
+
+	return users
+}
+
+// ValidateNetworks checks every network definition
+func ValidateNetworks() []error {
+	var errs []error
+
+	for _, networkName := range sortedNetworkNames() {
+		networkConfig := NetworkConfigs[networkName]
+		if validateErr := networkConfig.Validate(); validateErr != nil {
+			errs = append(errs, fmt.Errorf("network %s: %s", networkName, validateErr.Error()))
+		}
+	}
+
+	return errs
+}
+
+func sortedNetworkNames() []string {
+	names := make([]string, 0, len(NetworkConfigs))
+	for name := range NetworkConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
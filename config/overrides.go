@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// Folder (inside the config path) holding user override files, never touched by reload
+	overridesFolder = "overrides"
+
+	// Dist definitions as loaded, kept for diffing against the effective config
+	distProjectConfigs = map[string]Project{}
+)
+
+// FieldChange is a single field that differs between dist and the effective config
+type FieldChange struct {
+	Path      string
+	Dist      string
+	Effective string
+}
+
+// ProjectDiff describes how one effective project differs from its dist definition
+type ProjectDiff struct {
+	Key     string
+	Added   bool
+	Removed bool
+	Changes []FieldChange
+}
+
+func overridesPath() string {
+	return filepath.Join(configDir(), overridesFolder)
+}
+
+// override is one override file's changes to a single project
+type override struct {
+	file   string
+	values rawProject
+}
+
+// loadOverrides reads every *.json file in the overrides folder, in filename order
+func loadOverrides() (map[string][]override, error) {
+	overrides := map[string][]override{}
+
+	overrideFiles, readDirErr := ioutil.ReadDir(overridesPath())
+	if readDirErr != nil {
+		if os.IsNotExist(readDirErr) {
+			return overrides, nil
+		}
+		return nil, readDirErr
+	}
+
+	sort.Slice(overrideFiles, func(i, j int) bool {
+		return overrideFiles[i].Name() < overrideFiles[j].Name()
+	})
+
+	for _, overrideFile := range overrideFiles {
+		if overrideFile.IsDir() || !strings.HasSuffix(overrideFile.Name(), ".json") {
+			continue
+		}
+
+		fileBytes, fileReadErr := ioutil.ReadFile(filepath.Join(overridesPath(), overrideFile.Name()))
+		if fileReadErr != nil {
+			return nil, fileReadErr
+		}
+
+		var overrideSet map[string]rawProject
+		if parseErr := json.Unmarshal(fileBytes, &overrideSet); parseErr != nil {
+			return nil, fmt.Errorf("%s: %s", overrideFile.Name(), parseErr.Error())
+		}
+
+		for projectKey, values := range overrideSet {
+			overrides[projectKey] = append(overrides[projectKey], override{file: overrideFile.Name(), values: values})
+		}
+	}
+
+	return overrides, nil
+}
+
+// applyOverrides deep-merges user overrides on top of the given projects
+func applyOverrides(projects map[string]Project, overrides map[string][]override) (map[string]Project, []error) {
+	var errs []error
+
+	for projectKey, projectOverrides := range overrides {
+		project, exists := projects[projectKey]
+		if !exists {
+			errs = append(errs, fmt.Errorf("override for unknown project: %s", projectKey))
+			continue
+		}
+
+		raw, rawErr := toRaw(project)
+		if rawErr != nil {
+			errs = append(errs, rawErr)
+			continue
+		}
+
+		var mergeErr error
+		for _, projectOverride := range projectOverrides {
+			if raw, mergeErr = mergeRaw(raw, projectOverride.values); mergeErr != nil {
+				break
+			}
+		}
+		if mergeErr != nil {
+			errs = append(errs, fmt.Errorf("override %s: %s", projectKey, mergeErr.Error()))
+			continue
+		}
+
+		merged, parseErr := fromRaw(raw)
+		if parseErr != nil {
+			errs = append(errs, fmt.Errorf("override %s: %s", projectKey, parseErr.Error()))
+			continue
+		}
+
+		projects[projectKey] = merged
+	}
+
+	return projects, errs
+}
+
+// Diff compares the effective project configs with the dist definitions
+func Diff() []ProjectDiff {
+	diffs := []ProjectDiff{}
+
+	keys := map[string]string{}
+	for projectKey := range distProjectConfigs {
+		keys[projectKey] = projectKey
+	}
+	for projectKey := range ProjectConfigs {
+		keys[projectKey] = projectKey
+	}
+
+	resolvedDist, _ := resolveProjects(distProjectConfigs)
+
+	for _, projectKey := range sortedKeys(keys) {
+		distProject, inDist := resolvedDist[projectKey]
+		effectiveProject, inEffective := ProjectConfigs[projectKey]
+
+		if !inDist {
+			diffs = append(diffs, ProjectDiff{Key: projectKey, Added: true})
+			continue
+		}
+		if !inEffective {
+			diffs = append(diffs, ProjectDiff{Key: projectKey, Removed: true})
+			continue
+		}
+
+		distFlat := map[string]string{}
+		effectiveFlat := map[string]string{}
+		distRaw, _ := toRaw(distProject)
+		effectiveRaw, _ := toRaw(effectiveProject)
+		flattenRaw("", map[string]interface{}(distRaw), distFlat)
+		flattenRaw("", map[string]interface{}(effectiveRaw), effectiveFlat)
+
+		paths := map[string]string{}
+		for path := range distFlat {
+			paths[path] = path
+		}
+		for path := range effectiveFlat {
+			paths[path] = path
+		}
+
+		var changes []FieldChange
+		for _, path := range sortedKeys(paths) {
+			if distFlat[path] != effectiveFlat[path] {
+				changes = append(changes, FieldChange{Path: path, Dist: distFlat[path], Effective: effectiveFlat[path]})
+			}
+		}
+
+		if len(changes) > 0 {
+			diffs = append(diffs, ProjectDiff{Key: projectKey, Changes: changes})
+		}
+	}
+
+	return diffs
+}
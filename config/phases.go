@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+)
+
+const (
+	// Dependency kinds a phase follows: run or compile dependencies, both, or none at all
+	DependenciesRun     = "run"
+	DependenciesCompile = "compile"
+	DependenciesAll     = "all"
+	DependenciesNone    = "none"
+)
+
+var (
+	// Built-in phases and the dependencies they follow, their commands are build_cmd, run_cmd and
+	// test_cmd
+	builtinPhases = map[string]string{
+		PhaseBuild: DependenciesAll,
+		PhaseStart: DependenciesRun,
+		PhaseTest:  DependenciesNone,
+	}
+)
+
+// Phase is a named set of commands run in dependency order by pld run
+type Phase struct {
+	Cmd          []ShellCommand `json:"cmd,omitempty"`
+	Dependencies string         `json:"dependencies,omitempty"`
+}
+
+// IsBuiltinPhase reports whether a phase is build, start or test
+func IsBuiltinPhase(phase string) bool {
+	_, builtin := builtinPhases[phase]
+	return builtin
+}
+
+// PhaseCommands are the commands of a phase, build_cmd, run_cmd and test_cmd for the built-in ones
+func (p *Project) PhaseCommands(phase string) []ShellCommand {
+	switch phase {
+	case PhaseBuild:
+		return p.BuildCmd
+	case PhaseStart:
+		return p.RunCmd
+	case PhaseTest:
+		return p.TestCmd
+	}
+
+	return p.Phases[phase].Cmd
+}
+
+// PhasePrepare prepares the commands of a phase
+func (p *Project) PhasePrepare(phase string) ([]*exec.Cmd, error) {
+	return p.prepareCommands(p.PhaseCommands(phase))
+}
+
+// PhaseDependencies is the dependency kind a phase follows. Projects declaring the phase must agree
+// on it, and a phase no project declares is unknown.
+func PhaseDependencies(phase string, projects map[string]Project) (string, error) {
+	if dependencies, builtin := builtinPhases[phase]; builtin {
+		return dependencies, nil
+	}
+
+	dependencies := ""
+	declared := false
+	for _, projectKey := range sortedProjectKeys(projects) {
+		definition, exists := projects[projectKey].Phases[phase]
+		if !exists {
+			continue
+		}
+		declared = true
+
+		if definition.Dependencies == "" {
+			continue
+		}
+		if dependencies != "" && dependencies != definition.Dependencies {
+			return "", fmt.Errorf("projects disagree on the dependencies of phase %s: %s and %s", phase, dependencies, definition.Dependencies)
+		}
+		dependencies = definition.Dependencies
+	}
+
+	if !declared {
+		return "", fmt.Errorf("no project has a %s phase", phase)
+	}
+
+	if dependencies == "" {
+		return DependenciesAll, nil
+	}
+
+	return dependencies, nil
+}
+
+// PhaseNames lists the built-in phases and every phase a project declares
+func PhaseNames() []string {
+	phaseSet := map[string]bool{}
+	for phase := range builtinPhases {
+		phaseSet[phase] = true
+	}
+	for _, project := range ProjectConfigs {
+		for phase := range project.Phases {
+			phaseSet[phase] = true
+		}
+	}
+
+	return sortedSet(phaseSet)
+}
+
+// migrateTestPhases moves test phases, which projects declared before test_cmd existed, to
+// test_cmd. A project declaring both keeps test_cmd.
+func migrateTestPhases(projects map[string]Project) []string {
+	var warnings []string
+
+	for _, projectKey := range sortedProjectKeys(projects) {
+		project := projects[projectKey]
+		definition, declared := project.Phases[PhaseTest]
+		if !declared {
+			continue
+		}
+
+		phases := map[string]Phase{}
+		for phase, phaseDefinition := range project.Phases {
+			if phase != PhaseTest {
+				phases[phase] = phaseDefinition
+			}
+		}
+		project.Phases = phases
+
+		if len(project.TestCmd) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: phase %s ignored, test_cmd takes precedence", projectKey, PhaseTest))
+		} else {
+			project.TestCmd = definition.Cmd
+			warnings = append(warnings, fmt.Sprintf("%s: phase %s is built in, its commands are used as test_cmd, move them there", projectKey, PhaseTest))
+		}
+
+		projects[projectKey] = project
+	}
+
+	return warnings
+}
+
+// validatePhases checks phase names and dependency kinds
+func (p *Project) validatePhases() []error {
+	var errs []error
+
+	for phase, definition := range p.Phases {
+		// Test phases predate test_cmd and are migrated to it when configs load
+		if IsBuiltinPhase(phase) && phase != PhaseTest {
+			errs = append(errs, fmt.Errorf("phase %s is built in, use build_cmd, run_cmd or test_cmd", phase))
+		}
+
+		switch definition.Dependencies {
+		case "", DependenciesRun, DependenciesCompile, DependenciesAll, DependenciesNone:
+		default:
+			errs = append(errs, fmt.Errorf("phase %s: unknown dependencies %s", phase, definition.Dependencies))
+		}
+	}
+
+	return errs
+}
+
+func sortedProjectKeys(projects map[string]Project) []string {
+	projectKeys := make([]string, 0, len(projects))
+	for projectKey := range projects {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	return projectKeys
+}
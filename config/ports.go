@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Protocols of declared ports, tcp unless the port ends in /udp
+	PortProtocolTCP = "tcp"
+	PortProtocolUDP = "udp"
+)
+
+// Port is a host port a project declares, as <port> or <port>/<protocol>
+type Port struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+func (p Port) String() string {
+	return fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+}
+
+// ParsePort parses a port declaration such as 3306 or 8125/udp
+func ParsePort(declaration string) (Port, error) {
+	port := Port{Protocol: PortProtocolTCP}
+
+	number := declaration
+	if slash := strings.Index(declaration, "/"); slash >= 0 {
+		number, port.Protocol = declaration[:slash], declaration[slash+1:]
+	}
+
+	switch port.Protocol {
+	case PortProtocolTCP, PortProtocolUDP:
+	default:
+		return port, fmt.Errorf("port %s: unknown protocol %s", declaration, port.Protocol)
+	}
+
+	parsed, parseErr := strconv.Atoi(number)
+	if parseErr != nil || parsed < 1 || parsed > 65535 {
+		return port, fmt.Errorf("invalid port %s", declaration)
+	}
+	port.Port = parsed
+
+	return port, nil
+}
+
+// DeclaredPorts are the host ports of the project, placeholders and env variables expanded
+func (p *Project) DeclaredPorts() ([]Port, error) {
+	ports := []Port{}
+	for _, declaration := range p.Ports {
+		expanded, expandErr := p.expand(declaration, true)
+		if expandErr != nil {
+			return ports, fmt.Errorf("%s: %s", declaration, expandErr.Error())
+		}
+
+		port, parseErr := ParsePort(expanded)
+		if parseErr != nil {
+			return ports, parseErr
+		}
+		ports = append(ports, port)
+	}
+
+	return ports, nil
+}
+
+// validatePorts checks that every port declaration resolves to a port
+func (p *Project) validatePorts() []error {
+	var errs []error
+
+	declared := map[Port]bool{}
+	for _, declaration := range p.Ports {
+		expanded, expandErr := p.expand(declaration, false)
+		if expandErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", declaration, expandErr.Error()))
+			continue
+		}
+
+		port, parseErr := ParsePort(expanded)
+		if parseErr != nil {
+			errs = append(errs, parseErr)
+			continue
+		}
+		if declared[port] {
+			errs = append(errs, fmt.Errorf("port %s declared twice", port))
+		}
+		declared[port] = true
+	}
+
+	return errs
+}
+
+// PortUsers maps every declared port to the projects declaring it. Ports that do not resolve are
+// left out, project validation reports them.
+func PortUsers() map[Port][]string {
+	users := map[Port][]string{}
+
+	for _, projectKey := range sortedProjectKeys(ProjectConfigs) {
+		project := ProjectConfigs[projectKey]
+		ports, _ := project.DeclaredPorts()
+		for _, port := range ports {
+			if projectUsers := users[port]; len(projectUsers) == 0 || projectUsers[len(projectUsers)-1] != projectKey {
+				users[port] = append(users[port], projectKey)
+			}
+		}
+	}
+
+	return users
+}
+
+// SortedPorts orders ports by number, then protocol
+func SortedPorts(ports map[Port][]string) []Port {
+	sorted := make([]Port, 0, len(ports))
+	for port := range ports {
+		sorted = append(sorted, port)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Port != sorted[j].Port {
+			return sorted[i].Port < sorted[j].Port
+		}
+		return sorted[i].Protocol < sorted[j].Protocol
+	})
+
+	return sorted
+}
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		declaration string
+		want        Port
+		wantErr     bool
+	}{
+		{declaration: "3306", want: Port{Port: 3306, Protocol: PortProtocolTCP}},
+		{declaration: "8080/tcp", want: Port{Port: 8080, Protocol: PortProtocolTCP}},
+		{declaration: "8125/udp", want: Port{Port: 8125, Protocol: PortProtocolUDP}},
+		{declaration: "1", want: Port{Port: 1, Protocol: PortProtocolTCP}},
+		{declaration: "65535/udp", want: Port{Port: 65535, Protocol: PortProtocolUDP}},
+		{declaration: "0", wantErr: true},
+		{declaration: "65536", wantErr: true},
+		{declaration: "-80", wantErr: true},
+		{declaration: "http", wantErr: true},
+		{declaration: "", wantErr: true},
+		{declaration: "80/sctp", wantErr: true},
+		{declaration: "80/", wantErr: true},
+		{declaration: "8080:80", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.declaration, func(t *testing.T) {
+			port, parseErr := ParsePort(test.declaration)
+			if test.wantErr {
+				if parseErr == nil {
+					t.Fatalf("parsed to %s, want an error", port)
+				}
+				return
+			}
+			if parseErr != nil {
+				t.Fatal(parseErr)
+			}
+			if port != test.want {
+				t.Fatalf("parsed to %s, want %s", port, test.want)
+			}
+		})
+	}
+}
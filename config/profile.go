@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	// Folder (inside the config path) holding workspace profiles
+	profilesFolder = "profiles"
+
+	// For validation of profile names
+	profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+	// Profile selected with --profile, takes precedence over config.json
+	profileOverride string
+
+	// ActiveProfileName is the profile in use, empty when none is
+	ActiveProfileName string
+
+	// ActiveProfile holds the settings of the profile in use
+	ActiveProfile Profile
+)
+
+// Profile is a named environment setup, layered over the common config
+type Profile struct {
+	WorkspaceRoot string            `json:"workspace_root,omitempty"`
+	Variables     map[string]string `json:"variables,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Doctor        *DoctorProfile    `json:"doctor,omitempty"`
+}
+
+// DoctorProfile holds the expectations doctor checks for a profile
+type DoctorProfile struct {
+	Env map[string]string `json:"env,omitempty"`
+}
+
+func profilesPath() string {
+	return filepath.Join(configDir(), profilesFolder)
+}
+
+func profilePath(name string) string {
+	return filepath.Join(profilesPath(), name+".json")
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name: %s", name)
+	}
+
+	return nil
+}
+
+// SetProfileOverride selects a profile for this invocation only
+func SetProfileOverride(name string) {
+	profileOverride = name
+}
+
+// LoadProfile reads a profile by name
+func LoadProfile(name string) (Profile, error) {
+	var profile Profile
+
+	if nameErr := validateProfileName(name); nameErr != nil {
+		return profile, nameErr
+	}
+
+	profileFile, fileReadErr := ioutil.ReadFile(profilePath(name))
+	if fileReadErr != nil {
+		if errors.Is(fileReadErr, os.ErrNotExist) {
+			return profile, fmt.Errorf("profile %s does not exist", name)
+		}
+		return profile, fileReadErr
+	}
+
+	if parseErr := json.Unmarshal(profileFile, &profile); parseErr != nil {
+		return profile, fmt.Errorf("%s: %s", profilePath(name), parseErr.Error())
+	}
+
+	return profile, nil
+}
+
+// Profiles lists the names of every profile
+func Profiles() ([]string, error) {
+	names := []string{}
+
+	profileFiles, readDirErr := ioutil.ReadDir(profilesPath())
+	if readDirErr != nil {
+		if os.IsNotExist(readDirErr) {
+			return names, nil
+		}
+		return nil, readDirErr
+	}
+
+	for _, profileFile := range profileFiles {
+		if !profileFile.IsDir() && strings.HasSuffix(profileFile.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(profileFile.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// CreateProfile writes a new profile, starting from the current workspace root
+func CreateProfile(name string) error {
+	if nameErr := validateProfileName(name); nameErr != nil {
+		return nameErr
+	}
+
+	if _, statErr := os.Stat(profilePath(name)); statErr == nil {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+
+	if mkdirErr := os.MkdirAll(profilesPath(), os.ModePerm); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	profileJson, jsonErr := json.MarshalIndent(&Profile{WorkspaceRoot: Config.WorkspaceRoot}, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return ioutil.WriteFile(profilePath(name), profileJson, os.ModePerm)
+}
+
+// UseProfile makes a profile the default in config.json
+func UseProfile(name string) error {
+	if _, profileErr := LoadProfile(name); profileErr != nil {
+		return profileErr
+	}
+
+	return SetSetting("profile", name)
+}
+
+// applyProfile layers the selected profile over the common config. A profile selected with
+// --profile must exist, a missing default profile is only reported.
+func applyProfile(commonConfig *CommonConfig) error {
+	ActiveProfileName = ""
+	ActiveProfile = Profile{}
+
+	name := commonConfig.Profile
+	if profileOverride != "" {
+		name = profileOverride
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, profileErr := LoadProfile(name)
+	if profileErr != nil {
+		if profileOverride != "" {
+			return profileErr
+		}
+		return fmt.Errorf("ignoring default profile: %s", profileErr.Error())
+	}
+
+	ActiveProfileName = name
+	ActiveProfile = profile
+	commonConfig.Profile = name
+
+	if profile.WorkspaceRoot != "" && os.Getenv(envWorkspaceRoot) == "" {
+		commonConfig.WorkspaceRoot = absolutePath(profile.WorkspaceRoot)
+	}
+
+	return nil
+}
+
+// lookupEnv reads an environment variable, the active profile taking precedence
+func lookupEnv(name string) (string, bool) {
+	if value, inProfile := ActiveProfile.Env[name]; inProfile {
+		return value, true
+	}
+
+	return os.LookupEnv(name)
+}
+
+// CommandEnv is the environment commands run with, including the active profile's env vars
+func CommandEnv() []string {
+	env := os.Environ()
+	for name, value := range ActiveProfile.Env {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	return env
+}
+
+// Getenv reads an environment variable as commands see it
+func Getenv(name string) string {
+	value, _ := lookupEnv(name)
+	return value
+}
+
+func (p *Profile) Display() string {
+	out := strings.Builder{}
+	w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+
+	if len(p.WorkspaceRoot) > 0 {
+		_, _ = fmt.Fprintf(w, "Workspace root\t%s\n", p.WorkspaceRoot)
+	}
+
+	for _, name := range sortedKeys(p.Variables) {
+		_, _ = fmt.Fprintf(w, "Variable\t%s=%s\n", name, p.Variables[name])
+	}
+
+	for _, name := range sortedKeys(p.Env) {
+		_, _ = fmt.Fprintf(w, "Env\t%s=%s\n", name, p.Env[name])
+	}
+
+	if p.Doctor != nil {
+		for _, name := range sortedKeys(p.Doctor.Env) {
+			_, _ = fmt.Fprintf(w, "Doctor expects\t%s=%s\n", name, p.Doctor.Env[name])
+		}
+	}
+
+	_ = w.Flush()
+
+	return out.String()
+}
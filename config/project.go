@@ -4,18 +4,26 @@ import (
 	"context"
 	"fmt"
 	"github.com/docker/docker/api/types"
+	"github.com/manifoldco/promptui"
 	"github.com/poloniex/polo-local-dev/docker"
 	"github.com/poloniex/polo-local-dev/output"
 	"github.com/tufin/asciitree"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
 	"text/tabwriter"
 )
 
 var reverseGraph = map[string][]string{}
 
+const (
+	// Project types, shell projects run their build and run commands
+	ProjectTypeShell   = "shell"
+	ProjectTypeCompose = "compose"
+)
+
 type ProjectFile map[string]Project
 
 type DependsOn struct {
@@ -33,14 +41,35 @@ type ShellCommand struct {
 	Path    string `json:"path,omitempty"`
 }
 
+// ComposeConfig locates the compose service of a compose project
+type ComposeConfig struct {
+	File    string `json:"file,omitempty"`
+	Service string `json:"service,omitempty"`
+	Project string `json:"project,omitempty"`
+}
+
 type Project struct {
-	Repo             string           `json:"repo,omitempty"`
-	Name             string           `json:"name,omitempty"`
-	Groups           []string         `json:"groups,omitempty"`
-	DefaultVersion   string           `json:"default_version,omitempty"`
-	BuildCmd         []ShellCommand   `json:"build_cmd,omitempty"`
-	RunCmd           []ShellCommand   `json:"run_cmd,omitempty"`
-	DependsOn        DependsOn        `json:"depends_on,omitempty"`
+	Extends        string            `json:"extends,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	Repo           string            `json:"repo,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Groups         []string          `json:"groups,omitempty"`
+	DefaultVersion string            `json:"default_version,omitempty"`
+	BuildCmd       []ShellCommand    `json:"build_cmd,omitempty"`
+	RunCmd         []ShellCommand    `json:"run_cmd,omitempty"`
+	TestCmd        []ShellCommand    `json:"test_cmd,omitempty"`
+	TestReports    []string          `json:"test_reports,omitempty"`
+	Phases         map[string]Phase  `json:"phases,omitempty"`
+	DependsOn      DependsOn         `json:"depends_on,omitempty"`
+	Variables      map[string]string `json:"variables,omitempty"`
+	Compose        *ComposeConfig    `json:"compose,omitempty"`
+	Networks       []string          `json:"networks,omitempty"`
+	Ports          []string          `json:"ports,omitempty"`
+	Readiness      *Readiness        `json:"readiness,omitempty"`
+	Restart        string            `json:"restart,omitempty"`
+	Snapshot       *SnapshotConfig   `json:"snapshot,omitempty"`
+	Dev            *DevConfig        `json:"dev,omitempty"`
+	Hooks
 	ReverseDependsOn ReverseDependsOn `json:"-"`
 }
 
@@ -65,40 +94,33 @@ func (p *Project) GetRepoName() string {
 	return p.Name
 }
 
-func (p *Project) BuildPrepare() []*exec.Cmd {
-	buildCmds := make([]*exec.Cmd, len(p.BuildCmd))
-	for cmdIdx, cmd := range p.BuildCmd {
-		for oldString, newString := range p.stringReplacements() {
-			cmd.Command = strings.ReplaceAll(cmd.Command, oldString, newString)
-		}
-		cmdSplit := strings.Split(cmd.Command, " ")
-		buildCmds[cmdIdx] = exec.Command(cmdSplit[0], cmdSplit[1:]...)
-
-		for oldString, newString := range p.stringReplacements() {
-			cmd.Path = strings.ReplaceAll(cmd.Path, oldString, newString)
-		}
-		buildCmds[cmdIdx].Dir = cmd.Path
-	}
+func (p *Project) BuildPrepare() ([]*exec.Cmd, error) {
+	return p.PhasePrepare(PhaseBuild)
+}
 
-	return buildCmds
+func (p *Project) RunPrepare() ([]*exec.Cmd, error) {
+	return p.PhasePrepare(PhaseStart)
 }
 
-func (p *Project) RunPrepare() []*exec.Cmd {
-	runCmds := make([]*exec.Cmd, len(p.RunCmd))
-	for cmdIdx, cmd := range p.RunCmd {
-		for oldString, newString := range p.stringReplacements() {
-			cmd.Command = strings.ReplaceAll(cmd.Command, oldString, newString)
+func (p *Project) prepareCommands(commands []ShellCommand) ([]*exec.Cmd, error) {
+	cmds := make([]*exec.Cmd, len(commands))
+	for cmdIdx, cmd := range commands {
+		command, commandErr := p.expand(cmd.Command, true)
+		if commandErr != nil {
+			return nil, commandErr
 		}
-		cmdSplit := strings.Split(cmd.Command, " ")
-		runCmds[cmdIdx] = exec.Command(cmdSplit[0], cmdSplit[1:]...)
+		cmdSplit := strings.Split(command, " ")
+		cmds[cmdIdx] = exec.Command(cmdSplit[0], cmdSplit[1:]...)
 
-		for oldString, newString := range p.stringReplacements() {
-			cmd.Path = strings.ReplaceAll(cmd.Path, oldString, newString)
+		path, pathErr := p.expand(cmd.Path, true)
+		if pathErr != nil {
+			return nil, pathErr
 		}
-		runCmds[cmdIdx].Dir = cmd.Path
+		cmds[cmdIdx].Dir = path
+		cmds[cmdIdx].Env = CommandEnv()
 	}
 
-	return runCmds
+	return cmds, nil
 }
 
 func GetProjectsByGroup(group string) map[string]Project {
@@ -113,6 +135,27 @@ func GetProjectsByGroup(group string) map[string]Project {
 	return matchingProjects
 }
 
+// GetGroups maps every group to the keys of its member projects, including groups that only
+// have settings
+func GetGroups() map[string][]string {
+	groups := map[string][]string{}
+	for groupName := range GroupConfigs {
+		groups[groupName] = []string{}
+	}
+
+	for projectKey, projectConfig := range ProjectConfigs {
+		for _, projectGroup := range projectConfig.Groups {
+			groups[projectGroup] = append(groups[projectGroup], projectKey)
+		}
+	}
+
+	for groupName := range groups {
+		sort.Strings(groups[groupName])
+	}
+
+	return groups
+}
+
 func GetProjectByName(name string) Project {
 	for _, projectConfig := range ProjectConfigs {
 		if projectConfig.Name == name {
@@ -276,46 +319,156 @@ func GetProjectsByReverseGraph() map[string]Project {
 	return fullSet
 }
 
-func (p *Project) ContainerNameMatchers() (matchers []*regexp.Regexp) {
-	if len(p.RootPath()) == 0 || len(p.Name) == 0 {
-		matchers = append(matchers, regexp.MustCompile(fmt.Sprintf("\\/%s(_{1})%s(_{1})\\d+", p.Repo, p.Name)))
+// ContainerNameMatchers are the container name patterns of compose v1 (/<project>_<name>_1) and
+// v2 (/<project>-<name>-1), the ones prefixed with the repo first
+func (p *Project) ContainerNameMatchers() []*regexp.Regexp {
+	name := regexp.QuoteMeta(p.Name)
+
+	return append(p.repoContainerNameMatchers(),
+		regexp.MustCompile(fmt.Sprintf(`^/[a-zA-Z0-9_\-]+_%s_\d+$`, name)),
+		regexp.MustCompile(fmt.Sprintf(`^/[a-zA-Z0-9_\-]+-%s-\d+$`, name)),
+	)
+}
+
+// repoContainerNameMatchers are the container name patterns of compose v1 and v2 for the compose
+// project named after the repo
+func (p *Project) repoContainerNameMatchers() (matchers []*regexp.Regexp) {
+	if len(p.Repo) > 0 && len(p.Name) > 0 {
+		name := regexp.QuoteMeta(p.Name)
+		prefix := regexp.QuoteMeta(docker.NormalizeProjectName(p.Repo))
+		matchers = append(matchers,
+			regexp.MustCompile(fmt.Sprintf(`^/%s_%s_\d+$`, prefix, name)),
+			regexp.MustCompile(fmt.Sprintf(`^/%s-%s-\d+$`, prefix, name)),
+		)
 	}
-	matchers = append(matchers, regexp.MustCompile(fmt.Sprintf("\\/([a-zA-Z\\-]+)?(_{1})%s(_{1})\\d+", p.Name)))
 	return
 }
 
+// containerMatchers are the ways of recognising the project's container, most precise first: the
+// compose service and project labels, the service label alone, then the name patterns
+func (p *Project) containerMatchers() []func(types.Container) bool {
+	matchers := []func(types.Container) bool{
+		p.labelMatcher(),
+		func(container types.Container) bool {
+			return container.Labels[docker.LabelService] == p.Name
+		},
+	}
+
+	for _, nameMatcher := range p.ContainerNameMatchers() {
+		matchers = append(matchers, nameRegexpMatcher(nameMatcher))
+	}
+
+	return matchers
+}
+
+// preciseContainerMatchers only recognise containers of the compose project named after the repo,
+// by its labels or its container names
+func (p *Project) preciseContainerMatchers() []func(types.Container) bool {
+	matchers := []func(types.Container) bool{p.labelMatcher()}
+	for _, nameMatcher := range p.repoContainerNameMatchers() {
+		matchers = append(matchers, nameRegexpMatcher(nameMatcher))
+	}
+
+	return matchers
+}
+
+func (p *Project) labelMatcher() func(types.Container) bool {
+	repoProject := docker.NormalizeProjectName(p.GetRepoName())
+
+	return func(container types.Container) bool {
+		return container.Labels[docker.LabelService] == p.Name && container.Labels[docker.LabelProject] == repoProject
+	}
+}
+
+func nameRegexpMatcher(nameMatcher *regexp.Regexp) func(types.Container) bool {
+	return func(container types.Container) bool {
+		for _, containerName := range container.Names {
+			if nameMatcher.MatchString(containerName) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func (p *Project) FindRunningContainer() types.Container {
 	ctx := context.Background()
+
+	// Compose projects know their container exactly
+	if p.IsCompose() {
+		ref, refErr := p.ServiceRef()
+		if refErr != nil {
+			output.Error(refErr.Error())
+			return types.Container{}
+		}
+
+		serviceContainers, containerErr := docker.ServiceContainers(ctx, ref.Project, ref.Service, false)
+		if containerErr != nil {
+			output.Error(containerErr.Error())
+			return types.Container{}
+		}
+
+		if len(serviceContainers) == 0 {
+			output.Warning("Service container is not running")
+			return types.Container{}
+		}
+
+		return p.chooseContainer(serviceContainers)
+	}
+
 	containers, containerErr := docker.Containers(ctx)
 	if containerErr != nil {
 		output.Error(containerErr.Error())
 		return types.Container{}
 	}
 
-	matchers := p.ContainerNameMatchers()
-	matchingContainers := []types.Container{}
-	for _, container := range containers {
-		for _, containerName := range container.Names {
-			for _, matcher := range matchers {
-				if matcher.Match([]byte(containerName)) {
-					matchingContainers = append(matchingContainers, container)
-				}
+	for _, matcher := range p.containerMatchers() {
+		matchingContainers := []types.Container{}
+		for _, container := range containers {
+			if matcher(container) {
+				matchingContainers = append(matchingContainers, container)
 			}
 		}
+
+		if len(matchingContainers) > 0 {
+			return p.chooseContainer(matchingContainers)
+		}
 	}
 
-	if len(matchingContainers) > 1 {
-		output.Warning("Found multiple container matches")
+	output.Warning("Could not match container")
+	return types.Container{}
+}
+
+// chooseContainer picks the container to use among the matches, asking when there are several
+func (p *Project) chooseContainer(matchingContainers []types.Container) types.Container {
+	if len(matchingContainers) == 1 {
+		output.Ok(fmt.Sprintf("Found container %s", matchingContainers[0].ID[:10]))
+		return matchingContainers[0]
+	}
+
+	names := make([]string, 0, len(matchingContainers))
+	for _, container := range matchingContainers {
+		names = append(names, fmt.Sprintf("%s (%s, %s)", docker.ContainerName(container), container.Image, container.Status))
+	}
+
+	if !IsInteractive() {
+		output.Warning(fmt.Sprintf("Found multiple container matches: %s", strings.Join(names, ", ")))
 		return types.Container{}
 	}
 
-	if len(matchingContainers) == 0 {
-		output.Warning("Could not match container")
+	containerPrompt := promptui.Select{
+		Label: fmt.Sprintf("Container to use for %s", p.Name),
+		Items: names,
+	}
+
+	chosen, _, promptErr := containerPrompt.Run()
+	if promptErr != nil {
+		output.Warning("Found multiple container matches")
 		return types.Container{}
 	}
 
-	output.Ok(fmt.Sprintf("Found container %s", matchingContainers[0].ID[:10]))
-	return matchingContainers[0]
+	output.Ok(fmt.Sprintf("Using container %s", matchingContainers[chosen].ID[:10]))
+	return matchingContainers[chosen]
 }
 
 func (p *Project) Display() string {
@@ -334,6 +487,10 @@ func (p *Project) Display() string {
 		_, _ = fmt.Fprintf(w, "Repo\t%s\n", p.Repo)
 	}
 
+	if len(p.Extends) > 0 {
+		_, _ = fmt.Fprintf(w, "Template\t%s\n", p.Extends)
+	}
+
 	for groupIndex, group := range p.Groups {
 		if groupIndex == 0 {
 			_, _ = fmt.Fprintf(w, "Groups\t%s\n", group)
@@ -358,7 +515,123 @@ func (p *Project) Display() string {
 		}
 	}
 
-	for buildCmdIndex, buildCmd := range p.BuildPrepare() {
+	for _, name := range sortedKeys(p.Variables) {
+		_, _ = fmt.Fprintf(w, "Variable\t%s=%s\n", name, p.Variables[name])
+	}
+
+	for networkIndex, network := range p.Networks {
+		if networkIndex == 0 {
+			_, _ = fmt.Fprintf(w, "Networks\t%s\n", network)
+		} else {
+			_, _ = fmt.Fprintf(w, "\t%s\n", network)
+		}
+	}
+
+	for portIndex, port := range p.Ports {
+		if portIndex == 0 {
+			_, _ = fmt.Fprintf(w, "Ports\t%s\n", port)
+		} else {
+			_, _ = fmt.Fprintf(w, "\t%s\n", port)
+		}
+	}
+
+	if p.Readiness != nil {
+		for probeIndex, probe := range p.Readiness.Describe() {
+			if probeIndex == 0 {
+				_, _ = fmt.Fprintf(w, "Readiness\t%s\n", probe)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\n", probe)
+			}
+		}
+	}
+
+	if p.Snapshot != nil && len(p.Snapshot.MigrationVersionCmd) > 0 {
+		_, _ = fmt.Fprintf(w, "Migration version\t%s\n", strings.Join(p.Snapshot.MigrationVersionCmd, " "))
+	}
+
+	if p.Dev != nil {
+		for includeIndex, include := range p.Dev.Include {
+			if includeIndex == 0 {
+				_, _ = fmt.Fprintf(w, "Dev include\t%s\n", include)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\n", include)
+			}
+		}
+		for excludeIndex, exclude := range p.Dev.Exclude {
+			if excludeIndex == 0 {
+				_, _ = fmt.Fprintf(w, "Dev exclude\t%s\n", exclude)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\n", exclude)
+			}
+		}
+	}
+
+	phaseNames := make([]string, 0, len(p.Phases))
+	for phase := range p.Phases {
+		phaseNames = append(phaseNames, phase)
+	}
+	sort.Strings(phaseNames)
+	for _, phase := range phaseNames {
+		for cmdIndex, cmd := range p.Phases[phase].Cmd {
+			if cmdIndex == 0 {
+				_, _ = fmt.Fprintf(w, "Phase %s\t%s\n", phase, cmd.Command)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\n", cmd.Command)
+			}
+		}
+	}
+
+	for cmdIndex, cmd := range p.TestCmd {
+		if cmdIndex == 0 {
+			_, _ = fmt.Fprintf(w, "Test Commands\t%s\n", cmd.Command)
+		} else {
+			_, _ = fmt.Fprintf(w, "\t%s\n", cmd.Command)
+		}
+	}
+
+	for reportIndex, report := range p.TestReports {
+		if reportIndex == 0 {
+			_, _ = fmt.Fprintf(w, "Test reports\t%s\n", report)
+		} else {
+			_, _ = fmt.Fprintf(w, "\t%s\n", report)
+		}
+	}
+
+	for _, hook := range []string{HookPreBuild, HookPostBuild, HookPreStart, HookPostStart, HookOnFailure, HookPreStop} {
+		for cmdIndex, cmd := range p.Hooks.Commands(hook) {
+			if cmdIndex == 0 {
+				_, _ = fmt.Fprintf(w, "Hook %s\t%s\n", hook, cmd.Command)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\n", cmd.Command)
+			}
+		}
+	}
+
+	if len(p.Restart) > 0 {
+		_, _ = fmt.Fprintf(w, "Restart\t%s\n", p.Restart)
+	}
+
+	if p.IsCompose() {
+		_, _ = fmt.Fprintf(w, "Type\t%s\n", p.Type)
+
+		ref, refErr := p.ServiceRef()
+		if refErr != nil {
+			_, _ = fmt.Fprintf(w, "Compose service\tinvalid: %s\n", refErr.Error())
+		} else {
+			_, _ = fmt.Fprintf(w, "Compose file\t%s\n", ref.File.Path)
+			_, _ = fmt.Fprintf(w, "Compose service\t%s/%s\n", ref.Project, ref.Service)
+		}
+
+		_ = w.Flush()
+
+		return out.String()
+	}
+
+	buildCmds, buildPrepareErr := p.BuildPrepare()
+	if buildPrepareErr != nil {
+		_, _ = fmt.Fprintf(w, "Build Commands\tinvalid: %s\n", buildPrepareErr.Error())
+	}
+	for buildCmdIndex, buildCmd := range buildCmds {
 		if buildCmdIndex == 0 {
 			_, _ = fmt.Fprintf(w, "Build Commands\tcd %s && %s\n", buildCmd.Dir, buildCmd.String())
 		} else {
@@ -366,7 +639,11 @@ func (p *Project) Display() string {
 		}
 	}
 
-	for runCmdIndex, runCmd := range p.RunPrepare() {
+	runCmds, runPrepareErr := p.RunPrepare()
+	if runPrepareErr != nil {
+		_, _ = fmt.Fprintf(w, "Run Commands\tinvalid: %s\n", runPrepareErr.Error())
+	}
+	for runCmdIndex, runCmd := range runCmds {
 		if runCmdIndex == 0 {
 			_, _ = fmt.Fprintf(w, "Run Commands\tcd %s && %s\n", runCmd.Dir, runCmd.String())
 		} else {
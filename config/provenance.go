@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	SourceDist = "dist"
+	SourceUser = "user"
+)
+
+var (
+	// Manifest recording where each installed project came from
+	provenanceFile = "provenance.json"
+
+	// Provenances post-installation, keyed by project key
+	Provenances = map[string]Provenance{}
+)
+
+// Provenance records where an installed project came from
+type Provenance struct {
+	Source      string     `json:"source"`
+	File        string     `json:"file"`
+	DistSource  string     `json:"dist_source,omitempty"`
+	DistHash    string     `json:"dist_hash,omitempty"`
+	DistVersion string     `json:"dist_version,omitempty"`
+	InstalledAt *time.Time `json:"installed_at,omitempty"`
+
+	// Resolved at load time, not persisted
+	Overrides []string `json:"-"`
+	Modified  bool     `json:"-"`
+	Stale     bool     `json:"-"`
+}
+
+func provenancePath() string {
+	return filepath.Join(configDir(), provenanceFile)
+}
+
+func loadProvenance() (map[string]Provenance, error) {
+	provenance := map[string]Provenance{}
+
+	manifest, fileReadErr := ioutil.ReadFile(provenancePath())
+	if fileReadErr != nil {
+		if os.IsNotExist(fileReadErr) {
+			return provenance, nil
+		}
+		return nil, fileReadErr
+	}
+
+	if parseErr := json.Unmarshal(manifest, &provenance); parseErr != nil {
+		return nil, parseErr
+	}
+
+	return provenance, nil
+}
+
+func saveProvenance(provenance map[string]Provenance) error {
+	manifest, jsonErr := json.MarshalIndent(&provenance, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return ioutil.WriteFile(provenancePath(), manifest, os.ModePerm)
+}
+
+// projectHash fingerprints a project definition
+func projectHash(project Project) string {
+	projectJson, _ := json.Marshal(&project)
+	sum := sha256.Sum256(projectJson)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// distVersion fingerprints the whole dist set
+func distVersion(distConfigs map[string]Project) string {
+	projectKeys := make([]string, 0, len(distConfigs))
+	for projectKey := range distConfigs {
+		projectKeys = append(projectKeys, projectKey)
+	}
+	sort.Strings(projectKeys)
+
+	hash := sha256.New()
+	for _, projectKey := range projectKeys {
+		hash.Write([]byte(projectKey + projectHash(distConfigs[projectKey])))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// resolveProvenance fills in provenance for installed projects the manifest does not know about
+// and flags dist-originated projects that were modified locally or removed upstream
+func resolveProvenance(provenance map[string]Provenance, distConfigs, installedConfigs map[string]Project, installedFiles map[string]string) map[string]Provenance {
+	resolved := map[string]Provenance{}
+
+	for projectKey, project := range installedConfigs {
+		record, known := provenance[projectKey]
+		if !known {
+			if distProject, inDist := distConfigs[projectKey]; inDist && installedFiles[projectKey] == installedFileName(projectKey) {
+				record = Provenance{
+					Source:      SourceDist,
+					DistSource:  distProjectSources[projectKey],
+					DistHash:    projectHash(distProject),
+					DistVersion: distVersion(distConfigs),
+				}
+			} else if !inDist && installerWritten(projectKey, project, installedFiles[projectKey]) {
+				// Installed from the embedded dist before the manifest existed, and removed from it since
+				record = Provenance{Source: SourceDist, DistSource: SourceTypeEmbedded, DistHash: projectHash(project)}
+			} else {
+				record = Provenance{Source: SourceUser}
+			}
+		}
+		record.File = installedFiles[projectKey]
+
+		if record.Source == SourceDist {
+			if _, inDist := distConfigs[projectKey]; !inDist {
+				record.Stale = true
+			}
+			record.Modified = record.DistHash != projectHash(project)
+		}
+
+		resolved[projectKey] = record
+	}
+
+	return resolved
+}
+
+// pruneStaleConfigs removes dist-originated projects that no longer exist in dist. Stale projects
+// that were edited locally are only flagged.
+func pruneStaleConfigs(provenance map[string]Provenance) []string {
+	pruned := []string{}
+
+	for projectKey, record := range provenance {
+		if !record.Stale || record.Modified || record.File != installedFileName(projectKey) {
+			continue
+		}
+
+		if deleteErr := os.Remove(filepath.Join(configDir(), record.File)); deleteErr != nil {
+			continue
+		}
+
+		delete(provenance, projectKey)
+		pruned = append(pruned, projectKey)
+	}
+
+	sort.Strings(pruned)
+
+	return pruned
+}
+
+// installerWritten reports whether an installed file is exactly what installing the project from
+// dist writes, which hand-written files practically never are
+func installerWritten(projectKey string, project Project, file string) bool {
+	if file != installedFileName(projectKey) {
+		return false
+	}
+
+	fileBytes, fileReadErr := ioutil.ReadFile(filepath.Join(configDir(), file))
+	if fileReadErr != nil {
+		return false
+	}
+
+	projectJson, jsonErr := json.MarshalIndent(map[string]Project{projectKey: project}, "", "    ")
+	if jsonErr != nil {
+		return false
+	}
+
+	return bytes.Equal(bytes.TrimSpace(fileBytes), projectJson)
+}
+
+func installedFileName(projectKey string) string {
+	return fmt.Sprintf("%s.project.json", projectKey)
+}
+
+// GetProvenance returns the provenance of a project by key
+func GetProvenance(key string) Provenance {
+	return Provenances[key]
+}
+
+func (p Provenance) Status() string {
+	statuses := []string{}
+	if p.Stale {
+		statuses = append(statuses, "removed from dist")
+	}
+	if p.Modified {
+		statuses = append(statuses, "modified locally")
+	}
+	if len(statuses) == 0 {
+		return "ok"
+	}
+
+	return strings.Join(statuses, ", ")
+}
+
+func (p Provenance) Display() string {
+	out := strings.Builder{}
+	w := tabwriter.NewWriter(&out, 10, 0, 3, ' ', 0)
+
+	if p.Source == SourceDist {
+		_, _ = fmt.Fprintf(w, "Source\t%s %s (version %s, hash %s)\n", p.Source, p.DistSource, p.DistVersion, p.DistHash)
+	} else {
+		_, _ = fmt.Fprintf(w, "Source\t%s\n", p.Source)
+	}
+
+	if len(p.File) > 0 {
+		_, _ = fmt.Fprintf(w, "File\t%s\n", p.File)
+	}
+
+	if p.InstalledAt != nil {
+		_, _ = fmt.Fprintf(w, "Installed\t%s\n", p.InstalledAt.Format(time.RFC3339))
+	}
+
+	for overrideIndex, override := range p.Overrides {
+		if overrideIndex == 0 {
+			_, _ = fmt.Fprintf(w, "Overrides\t%s\n", override)
+		} else {
+			_, _ = fmt.Fprintf(w, "\t%s\n", override)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Status\t%s\n", p.Status())
+
+	_ = w.Flush()
+
+	return out.String()
+}
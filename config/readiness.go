@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultProbeInterval = time.Second
+	defaultProbeTimeout  = time.Minute
+)
+
+// Readiness holds the probes that must all pass before a project counts as started
+type Readiness struct {
+	TCP  *TCPProbe  `json:"tcp,omitempty"`
+	HTTP *HTTPProbe `json:"http,omitempty"`
+	Exec *ExecProbe `json:"exec,omitempty"`
+	Log  *LogProbe  `json:"log,omitempty"`
+}
+
+// ProbeTiming is how often a probe is tried and how long it may take to pass
+type ProbeTiming struct {
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+// TCPProbe passes once the port accepts connections
+type TCPProbe struct {
+	ProbeTiming
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port"`
+}
+
+// HTTPProbe passes once a GET returns the expected status, 200 by default
+type HTTPProbe struct {
+	ProbeTiming
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+}
+
+// ExecProbe passes once the command exits 0 inside the container
+type ExecProbe struct {
+	ProbeTiming
+	Command []string `json:"command"`
+}
+
+// LogProbe passes once a container log line matches the pattern
+type LogProbe struct {
+	ProbeTiming
+	Pattern string `json:"pattern"`
+}
+
+// Durations parses the timing, applying the defaults. Both must be positive.
+func (t *ProbeTiming) Durations() (time.Duration, time.Duration, error) {
+	interval, timeout := defaultProbeInterval, defaultProbeTimeout
+
+	if t.Interval != "" {
+		var parseErr error
+		if interval, parseErr = time.ParseDuration(t.Interval); parseErr != nil {
+			return interval, timeout, fmt.Errorf("interval: %s", parseErr.Error())
+		}
+	}
+
+	if t.Timeout != "" {
+		var parseErr error
+		if timeout, parseErr = time.ParseDuration(t.Timeout); parseErr != nil {
+			return interval, timeout, fmt.Errorf("timeout: %s", parseErr.Error())
+		}
+	}
+
+	if interval <= 0 {
+		return interval, timeout, fmt.Errorf("interval: must be positive, got %s", t.Interval)
+	}
+	if timeout <= 0 {
+		return interval, timeout, fmt.Errorf("timeout: must be positive, got %s", t.Timeout)
+	}
+
+	return interval, timeout, nil
+}
+
+// Address is where the TCP probe connects, localhost by default
+func (p *TCPProbe) Address() string {
+	host := p.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	return fmt.Sprintf("%s:%d", host, p.Port)
+}
+
+// ExpectedStatus is the status the HTTP probe waits for
+func (p *HTTPProbe) ExpectedStatus() int {
+	if p.Status == 0 {
+		return 200
+	}
+
+	return p.Status
+}
+
+// Validate checks every configured probe
+func (r *Readiness) Validate() []error {
+	var errs []error
+
+	check := func(name string, timing ProbeTiming, probeErr error) {
+		if _, _, timingErr := timing.Durations(); timingErr != nil {
+			errs = append(errs, fmt.Errorf("readiness.%s: %s", name, timingErr.Error()))
+		}
+		if probeErr != nil {
+			errs = append(errs, fmt.Errorf("readiness.%s: %s", name, probeErr.Error()))
+		}
+	}
+
+	if r.TCP != nil {
+		var portErr error
+		if r.TCP.Port <= 0 || r.TCP.Port > 65535 {
+			portErr = fmt.Errorf("invalid port %d", r.TCP.Port)
+		}
+		check("tcp", r.TCP.ProbeTiming, portErr)
+	}
+
+	if r.HTTP != nil {
+		var urlErr error
+		if r.HTTP.URL == "" {
+			urlErr = fmt.Errorf("url is required")
+		}
+		check("http", r.HTTP.ProbeTiming, urlErr)
+	}
+
+	if r.Exec != nil {
+		var commandErr error
+		if len(r.Exec.Command) == 0 {
+			commandErr = fmt.Errorf("command is required")
+		}
+		check("exec", r.Exec.ProbeTiming, commandErr)
+	}
+
+	if r.Log != nil {
+		_, patternErr := regexp.Compile(r.Log.Pattern)
+		if r.Log.Pattern == "" {
+			patternErr = fmt.Errorf("pattern is required")
+		}
+		check("log", r.Log.ProbeTiming, patternErr)
+	}
+
+	return errs
+}
+
+// Describe lists the configured probes in words
+func (r *Readiness) Describe() []string {
+	descriptions := []string{}
+
+	if r.TCP != nil {
+		descriptions = append(descriptions, fmt.Sprintf("tcp %s", r.TCP.Address()))
+	}
+	if r.HTTP != nil {
+		descriptions = append(descriptions, fmt.Sprintf("http GET %s expects %d", r.HTTP.URL, r.HTTP.ExpectedStatus()))
+	}
+	if r.Exec != nil {
+		descriptions = append(descriptions, fmt.Sprintf("exec %v", r.Exec.Command))
+	}
+	if r.Log != nil {
+		descriptions = append(descriptions, fmt.Sprintf("log /%s/", r.Log.Pattern))
+	}
+
+	return descriptions
+}
+
+// ExpandedReadiness is the project's readiness with placeholders resolved in hosts, URLs and commands
+func (p *Project) ExpandedReadiness() (Readiness, error) {
+	readiness := *p.Readiness
+
+	if readiness.TCP != nil {
+		tcp := *readiness.TCP
+		host, expandErr := p.expand(tcp.Host, true)
+		if expandErr != nil {
+			return readiness, expandErr
+		}
+		tcp.Host = host
+		readiness.TCP = &tcp
+	}
+
+	if readiness.HTTP != nil {
+		http := *readiness.HTTP
+		url, expandErr := p.expand(http.URL, true)
+		if expandErr != nil {
+			return readiness, expandErr
+		}
+		http.URL = url
+		readiness.HTTP = &http
+	}
+
+	if readiness.Exec != nil {
+		exec := *readiness.Exec
+		exec.Command = make([]string, len(readiness.Exec.Command))
+		for argIndex, arg := range readiness.Exec.Command {
+			expanded, expandErr := p.expand(arg, true)
+			if expandErr != nil {
+				return readiness, expandErr
+			}
+			exec.Command[argIndex] = expanded
+		}
+		readiness.Exec = &exec
+	}
+
+	return readiness, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestProbeTimingDurations(t *testing.T) {
+	tests := []struct {
+		interval string
+		timeout  string
+		wantErr  bool
+	}{
+		{},
+		{interval: "500ms", timeout: "30s"},
+		{interval: "0s", wantErr: true},
+		{interval: "-1s", wantErr: true},
+		{timeout: "0s", wantErr: true},
+		{timeout: "-5m", wantErr: true},
+		{interval: "soon", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.interval+"/"+test.timeout, func(t *testing.T) {
+			timing := ProbeTiming{Interval: test.interval, Timeout: test.timeout}
+			interval, timeout, durationsErr := timing.Durations()
+			if test.wantErr {
+				if durationsErr == nil {
+					t.Fatalf("got %s and %s, want an error", interval, timeout)
+				}
+				return
+			}
+			if durationsErr != nil {
+				t.Fatal(durationsErr)
+			}
+			if interval <= 0 || timeout <= 0 {
+				t.Fatalf("got %s and %s", interval, timeout)
+			}
+		})
+	}
+
+	readiness := Readiness{TCP: &TCPProbe{Port: 3306, ProbeTiming: ProbeTiming{Interval: "0s"}}}
+	if errs := readiness.Validate(); len(errs) != 1 {
+		t.Fatalf("validate gave %v, want the interval error", errs)
+	}
+}
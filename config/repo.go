@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const SourceRepo = "repo"
+
+var (
+	// Project config files discovered at the root of each repo, in order of preference
+	repoConfigFiles = []string{".pld.json", ".pld.yaml", ".pld.yml"}
+)
+
+// loadRepoConfigs discovers project config files at the root of every repo in the workspace. When
+// two repos declare the same project, the first repo alphabetically wins and the conflict is reported.
+func loadRepoConfigs() (map[string]Project, map[string]string, []error) {
+	repoConfigs := map[string]Project{}
+	repoFiles := map[string]string{}
+	var errs []error
+
+	if Config.WorkspaceRoot == "" {
+		return repoConfigs, repoFiles, nil
+	}
+
+	workspaceEntries, readDirErr := ioutil.ReadDir(Config.WorkspaceRoot)
+	if readDirErr != nil {
+		if os.IsNotExist(readDirErr) {
+			return repoConfigs, repoFiles, nil
+		}
+		return repoConfigs, repoFiles, []error{readDirErr}
+	}
+
+	sort.Slice(workspaceEntries, func(i, j int) bool {
+		return workspaceEntries[i].Name() < workspaceEntries[j].Name()
+	})
+
+	for _, workspaceEntry := range workspaceEntries {
+		if !workspaceEntry.IsDir() {
+			continue
+		}
+
+		repoConfig, repoFile, loadErr := loadRepoConfig(workspaceEntry.Name())
+		if loadErr != nil {
+			errs = append(errs, loadErr)
+			continue
+		}
+
+		for projectKey, project := range repoConfig {
+			if existingFile, exists := repoFiles[projectKey]; exists {
+				errs = append(errs, fmt.Errorf("%s: declared in both %s and %s, using %s", projectKey, existingFile, repoFile, existingFile))
+				continue
+			}
+
+			repoConfigs[projectKey] = project
+			repoFiles[projectKey] = repoFile
+		}
+	}
+
+	return repoConfigs, repoFiles, errs
+}
+
+// loadRepoConfig reads the project config file of a single repo, resolving repo-local paths
+func loadRepoConfig(repo string) (ProjectFile, string, error) {
+	repoPath := filepath.Join(Config.WorkspaceRoot, repo)
+
+	for _, configFile := range repoConfigFiles {
+		fileBytes, fileReadErr := ioutil.ReadFile(filepath.Join(repoPath, configFile))
+		if fileReadErr != nil {
+			if os.IsNotExist(fileReadErr) {
+				continue
+			}
+			return nil, "", fileReadErr
+		}
+
+		repoFile := filepath.Join(repo, configFile)
+
+		// YAML is converted to JSON so both formats share the json tags on Project
+		if strings.HasSuffix(configFile, ".yaml") || strings.HasSuffix(configFile, ".yml") {
+			var yamlConfig interface{}
+			if parseErr := yaml.Unmarshal(fileBytes, &yamlConfig); parseErr != nil {
+				return nil, "", fmt.Errorf("%s: %s", repoFile, parseErr.Error())
+			}
+
+			var jsonErr error
+			if fileBytes, jsonErr = json.Marshal(yamlConfig); jsonErr != nil {
+				return nil, "", fmt.Errorf("%s: %s", repoFile, jsonErr.Error())
+			}
+		}
+
+		repoConfigFile, parseErr := parseProjectFile(fileBytes)
+		if parseErr != nil {
+			return nil, "", fmt.Errorf("%s: %s", repoFile, parseErr.Error())
+		}
+		repoConfigFile.register()
+		repoConfig := repoConfigFile.projects
+
+		for projectKey, project := range repoConfig {
+			if project.Repo == "" {
+				project.Repo = repo
+			}
+			project.BuildCmd = resolveRepoPaths(repoPath, project.BuildCmd)
+			project.RunCmd = resolveRepoPaths(repoPath, project.RunCmd)
+			project.TestCmd = resolveRepoPaths(repoPath, project.TestCmd)
+			project.Hooks = project.Hooks.resolveRepoPaths(repoPath)
+			if len(project.Phases) > 0 {
+				phases := make(map[string]Phase, len(project.Phases))
+				for name, phase := range project.Phases {
+					phase.Cmd = resolveRepoPaths(repoPath, phase.Cmd)
+					phases[name] = phase
+				}
+				project.Phases = phases
+			}
+			repoConfig[projectKey] = project
+		}
+
+		return repoConfig, repoFile, nil
+	}
+
+	return nil, "", nil
+}
+
+// resolveRepoPaths makes relative command paths relative to the repo. Paths starting with a
+// placeholder are left for string replacement.
+func resolveRepoPaths(repoPath string, commands []ShellCommand) []ShellCommand {
+	resolved := make([]ShellCommand, len(commands))
+	for cmdIdx, cmd := range commands {
+		if !filepath.IsAbs(cmd.Path) && !strings.HasPrefix(cmd.Path, "#") {
+			cmd.Path = filepath.Join(repoPath, cmd.Path)
+		}
+		resolved[cmdIdx] = cmd
+	}
+
+	return resolved
+}
+
+// mergeRepoConfigs layers repo-local projects over the installed ones. Repo files take precedence
+// over dist definitions, user-created projects take precedence over repo files. Every conflict is
+// reported.
+func mergeRepoConfigs(installedConfigs, repoConfigs map[string]Project, repoFiles map[string]string, provenance map[string]Provenance) []string {
+	var conflicts []string
+
+	for projectKey, project := range repoConfigs {
+		record, installed := provenance[projectKey]
+		if installed && record.Source == SourceUser {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s ignored, user-created %s takes precedence", projectKey, repoFiles[projectKey], record.File))
+			continue
+		}
+		if installed {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s takes precedence over %s", projectKey, repoFiles[projectKey], record.File))
+		}
+
+		installedConfigs[projectKey] = project
+		provenance[projectKey] = Provenance{Source: SourceRepo, File: repoFiles[projectKey]}
+	}
+
+	sort.Strings(conflicts)
+
+	return conflicts
+}
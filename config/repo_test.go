@@ -0,0 +1,68 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRepoConfigResolvesPaths(t *testing.T) {
+	savedConfig := Config
+	defer func() { Config = savedConfig }()
+	Config.WorkspaceRoot = t.TempDir()
+
+	repoPath := filepath.Join(Config.WorkspaceRoot, "spot")
+	if mkdirErr := os.MkdirAll(repoPath, os.ModePerm); mkdirErr != nil {
+		t.Fatal(mkdirErr)
+	}
+	repoYaml := `
+spot:
+  name: spot
+  build_cmd:
+    - command: make build
+      path: build
+  pre_start:
+    - command: ./migrate.sh
+      path: scripts
+  on_failure:
+    - command: ./dump.sh
+      path: "#PROJECT_ROOT#/scripts"
+  phases:
+    lint:
+      cmd:
+        - command: make lint
+          path: tools
+        - command: make vet
+          path: /opt/tools
+`
+	if writeErr := ioutil.WriteFile(filepath.Join(repoPath, ".pld.yaml"), []byte(repoYaml), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	repoConfig, _, loadErr := loadRepoConfig("spot")
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+	project := repoConfig["spot"]
+
+	paths := map[string]string{
+		"build_cmd":  project.BuildCmd[0].Path,
+		"pre_start":  project.PreStart[0].Path,
+		"on_failure": project.OnFailure[0].Path,
+		"lint":       project.Phases["lint"].Cmd[0].Path,
+		"lint abs":   project.Phases["lint"].Cmd[1].Path,
+	}
+	want := map[string]string{
+		"build_cmd":  filepath.Join(repoPath, "build"),
+		"pre_start":  filepath.Join(repoPath, "scripts"),
+		"on_failure": "#PROJECT_ROOT#/scripts",
+		"lint":       filepath.Join(repoPath, "tools"),
+		"lint abs":   "/opt/tools",
+	}
+	for name, path := range paths {
+		if path != want[name] {
+			t.Errorf("%s path is %s, want %s", name, path, want[name])
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	// Folder (inside the config path) holding test reports, one folder per pld test
+	reportsFolder = "reports"
+
+	// Merged JUnit report and summary inside a report folder
+	reportJUnitFile   = "junit.xml"
+	reportSummaryFile = "summary.json"
+)
+
+// TestResult is the outcome of the test commands of one project
+type TestResult struct {
+	Project  string   `json:"project"`
+	Passed   bool     `json:"passed"`
+	Seconds  float64  `json:"seconds"`
+	Error    string   `json:"error,omitempty"`
+	Log      string   `json:"log"`
+	Reports  []string `json:"reports"`
+	Tests    int      `json:"tests"`
+	Failures int      `json:"failures"`
+	Errors   int      `json:"errors"`
+	Skipped  int      `json:"skipped"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Duration is how long the test commands of the project ran
+func (r *TestResult) Duration() time.Duration {
+	return time.Duration(r.Seconds * float64(time.Second))
+}
+
+// TestReport is the results of one pld test, merged into a single JUnit report
+type TestReport struct {
+	Date    time.Time    `json:"date"`
+	Path    string       `json:"path"`
+	JUnit   string       `json:"junit"`
+	Passed  bool         `json:"passed"`
+	Seconds float64      `json:"seconds"`
+	Results []TestResult `json:"results"`
+	suites  []junitSuite
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr,omitempty"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite keeps the test cases of a suite as they were written
+type junitSuite struct {
+	XMLName   xml.Name `xml:"testsuite"`
+	Name      string   `xml:"name,attr"`
+	Tests     int      `xml:"tests,attr"`
+	Failures  int      `xml:"failures,attr"`
+	Errors    int      `xml:"errors,attr"`
+	Skipped   int      `xml:"skipped,attr"`
+	Time      string   `xml:"time,attr,omitempty"`
+	Timestamp string   `xml:"timestamp,attr,omitempty"`
+	Inner     []byte   `xml:",innerxml"`
+}
+
+type junitCase struct {
+	XMLName   xml.Name      `xml:"testcase"`
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func reportsPath() string {
+	return filepath.Join(configDir(), reportsFolder)
+}
+
+// NewTestReport creates the folder of a new test report
+func NewTestReport() (TestReport, error) {
+	now := time.Now()
+	path := filepath.Join(reportsPath(), now.Format("2006-01-02_15-04-05"))
+	for suffix := 2; ; suffix++ {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			break
+		}
+		path = filepath.Join(reportsPath(), fmt.Sprintf("%s-%d", now.Format("2006-01-02_15-04-05"), suffix))
+	}
+
+	report := TestReport{
+		Date:    now,
+		Path:    path,
+		JUnit:   filepath.Join(path, reportJUnitFile),
+		Passed:  true,
+		Results: []TestResult{},
+	}
+
+	return report, os.MkdirAll(path, os.ModePerm)
+}
+
+// LogPath is the file the test commands of a project write their output to
+func (r *TestReport) LogPath(projectKey string) string {
+	return filepath.Join(r.Path, projectKey+".log")
+}
+
+// TestReportFiles finds the JUnit files named in test_reports written since the tests started.
+// Relative paths are relative to the project root, and may be globs.
+func (p *Project) TestReportFiles(since time.Time) ([]string, error) {
+	files := []string{}
+	for _, pattern := range p.TestReports {
+		expanded, expandErr := p.expand(pattern, true)
+		if expandErr != nil {
+			return files, expandErr
+		}
+		if !filepath.IsAbs(expanded) {
+			expanded = filepath.Join(p.RootPath(), expanded)
+		}
+
+		matches, globErr := filepath.Glob(expanded)
+		if globErr != nil {
+			return files, fmt.Errorf("%s: %s", pattern, globErr.Error())
+		}
+
+		// Left over from an earlier run
+		for _, match := range matches {
+			if info, statErr := os.Stat(match); statErr == nil && !info.IsDir() && !info.ModTime().Before(since) {
+				files = append(files, match)
+			}
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// AddResult records the result of a project and merges the JUnit files it wrote. Projects without
+// JUnit files, or failing without a failed test case, get a test case of their own.
+func (r *TestReport) AddResult(result TestResult) {
+	suites := []junitSuite{}
+	for _, reportFile := range result.Reports {
+		fileSuites, readErr := readJUnit(reportFile)
+		if readErr != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", reportFile, readErr.Error()))
+			continue
+		}
+		suites = append(suites, fileSuites...)
+	}
+
+	for suiteIndex := range suites {
+		suites[suiteIndex].Name = fmt.Sprintf("%s/%s", result.Project, suites[suiteIndex].Name)
+		result.Tests += suites[suiteIndex].Tests
+		result.Failures += suites[suiteIndex].Failures
+		result.Errors += suites[suiteIndex].Errors
+		result.Skipped += suites[suiteIndex].Skipped
+	}
+
+	if len(suites) == 0 || (!result.Passed && result.Failures+result.Errors == 0) {
+		suite := result.commandSuite()
+		suites = append(suites, suite)
+		result.Tests += suite.Tests
+		result.Failures += suite.Failures
+	}
+
+	r.Passed = r.Passed && result.Passed
+	r.Results = append(r.Results, result)
+	r.suites = append(r.suites, suites...)
+}
+
+// commandSuite is a suite with a single test case standing for the test commands of the project
+func (r *TestResult) commandSuite() junitSuite {
+	testCase := junitCase{
+		Name:      "test_cmd",
+		Classname: r.Project,
+		Time:      fmt.Sprintf("%.3f", r.Seconds),
+	}
+
+	suite := junitSuite{Name: r.Project, Tests: 1, Time: testCase.Time}
+	if !r.Passed {
+		testCase.Failure = &junitFailure{Message: r.Error, Content: fmt.Sprintf("Output: %s", r.Log)}
+		suite.Failures = 1
+	}
+
+	suite.Inner, _ = xml.Marshal(testCase)
+
+	return suite
+}
+
+// Save writes the merged JUnit report and the summary of the results
+func (r *TestReport) Save() error {
+	r.Seconds = time.Since(r.Date).Seconds()
+	sort.Slice(r.Results, func(i, j int) bool {
+		return r.Results[i].Project < r.Results[j].Project
+	})
+
+	merged := junitSuites{Name: "pld test", Time: fmt.Sprintf("%.3f", r.Seconds), Suites: r.suites}
+	for _, suite := range r.suites {
+		merged.Tests += suite.Tests
+		merged.Failures += suite.Failures
+		merged.Errors += suite.Errors
+		merged.Skipped += suite.Skipped
+	}
+
+	junitXml, xmlErr := xml.MarshalIndent(merged, "", "    ")
+	if xmlErr != nil {
+		return xmlErr
+	}
+	if writeErr := ioutil.WriteFile(r.JUnit, append([]byte(xml.Header), junitXml...), 0644); writeErr != nil {
+		return writeErr
+	}
+
+	summaryJson, jsonErr := json.MarshalIndent(r, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return ioutil.WriteFile(filepath.Join(r.Path, reportSummaryFile), summaryJson, 0644)
+}
+
+// readJUnit reads the suites of a JUnit file, written either as <testsuites> or a single <testsuite>
+func readJUnit(path string) ([]junitSuite, error) {
+	junitBytes, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	suites := junitSuites{}
+	if suitesErr := xml.Unmarshal(junitBytes, &suites); suitesErr == nil {
+		return suites.Suites, nil
+	}
+
+	suite := junitSuite{}
+	if suiteErr := xml.Unmarshal(junitBytes, &suite); suiteErr != nil {
+		return nil, suiteErr
+	}
+
+	return []junitSuite{suite}, nil
+}
+
+// PruneTestReports removes the reports older than the log retention
+func PruneTestReports() error {
+	entries, readErr := ioutil.ReadDir(reportsPath())
+	if os.IsNotExist(readErr) {
+		return nil
+	} else if readErr != nil {
+		return readErr
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -Config.LogRetentionDays)
+	for _, entry := range entries {
+		if entry.IsDir() && entry.ModTime().Before(cutoff) {
+			if removeErr := os.RemoveAll(filepath.Join(reportsPath(), entry.Name())); removeErr != nil {
+				return removeErr
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeJUnit writes a JUnit file to dir, returning its path
+func writeJUnit(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if writeErr := ioutil.WriteFile(path, []byte(content), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	return path
+}
+
+func TestReadJUnit(t *testing.T) {
+	dir := t.TempDir()
+
+	suites, readErr := readJUnit(writeJUnit(t, dir, "suites.xml", `<?xml version="1.0"?>
+<testsuites>
+  <testsuite name="orders" tests="2" failures="1"><testcase name="a"/><testcase name="b"><failure message="x"/></testcase></testsuite>
+  <testsuite name="fills" tests="1" skipped="1"><testcase name="c"><skipped/></testcase></testsuite>
+</testsuites>`))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(suites) != 2 || suites[0].Name != "orders" || suites[0].Failures != 1 || suites[1].Skipped != 1 {
+		t.Fatalf("read %+v", suites)
+	}
+
+	suites, readErr = readJUnit(writeJUnit(t, dir, "suite.xml", `<testsuite name="single" tests="3" errors="1"></testsuite>`))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(suites) != 1 || suites[0].Name != "single" || suites[0].Tests != 3 || suites[0].Errors != 1 {
+		t.Fatalf("read %+v", suites)
+	}
+
+	if _, readErr = readJUnit(writeJUnit(t, dir, "broken.xml", `<testsuite name="broken"`)); readErr == nil {
+		t.Fatal("broken file read without an error")
+	}
+}
+
+func TestAddResultMergesJUnit(t *testing.T) {
+	dir := t.TempDir()
+	report := TestReport{Path: dir, JUnit: filepath.Join(dir, reportJUnitFile), Passed: true}
+
+	report.AddResult(TestResult{
+		Project: "spot",
+		Passed:  false,
+		Reports: []string{
+			writeJUnit(t, dir, "spot.xml", `<testsuites><testsuite name="orders" tests="4" failures="1" skipped="1"></testsuite></testsuites>`),
+			filepath.Join(dir, "missing.xml"),
+		},
+	})
+	// Passing without a JUnit file
+	report.AddResult(TestResult{Project: "wallet", Passed: true})
+	// Failing without a failed test case
+	report.AddResult(TestResult{
+		Project: "margin",
+		Passed:  false,
+		Error:   "exit status 2",
+		Reports: []string{writeJUnit(t, dir, "margin.xml", `<testsuite name="risk" tests="2"></testsuite>`)},
+	})
+
+	if report.Passed {
+		t.Fatal("report passed with failing projects")
+	}
+
+	spot, wallet, margin := report.Results[0], report.Results[1], report.Results[2]
+	if spot.Tests != 4 || spot.Failures != 1 || spot.Skipped != 1 || len(spot.Warnings) != 1 {
+		t.Fatalf("spot %+v", spot)
+	}
+	if wallet.Tests != 1 || wallet.Failures != 0 {
+		t.Fatalf("wallet %+v", wallet)
+	}
+	if margin.Tests != 3 || margin.Failures != 1 {
+		t.Fatalf("margin %+v", margin)
+	}
+
+	names := []string{}
+	for _, suite := range report.suites {
+		names = append(names, suite.Name)
+	}
+	if want := "spot/orders|wallet|margin/risk|margin"; strings.Join(names, "|") != want {
+		t.Fatalf("suites %s, want %s", strings.Join(names, "|"), want)
+	}
+
+	if saveErr := report.Save(); saveErr != nil {
+		t.Fatal(saveErr)
+	}
+	merged, readErr := readJUnit(report.JUnit)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(merged) != 4 {
+		t.Fatalf("merged report has %d suites, want 4", len(merged))
+	}
+}
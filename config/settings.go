@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	SettingSourceDefault = "default"
+	SettingSourceFile    = "config.json"
+	SettingSourceEnv     = "env"
+	SettingSourceProfile = "profile"
+	SettingSourceFlag    = "flag --profile"
+)
+
+// Setting documents and type-checks a scalar key of the common config
+type Setting struct {
+	Key         string
+	Description string
+	Default     string
+	Allowed     []string
+	get         func(c *CommonConfig) string
+	set         func(c *CommonConfig, value string) error
+}
+
+// Settings are the keys available to `pld config get/set`
+var Settings = []Setting{
+	{
+		Key:         "workspace_root",
+		Description: "Folder repos are cloned into",
+		get:         func(c *CommonConfig) string { return c.WorkspaceRoot },
+		set: func(c *CommonConfig, value string) error {
+			if !pathRegex.MatchString(absolutePath(value)) {
+				return errors.New("invalid path")
+			}
+			c.WorkspaceRoot = absolutePath(value)
+			return nil
+		},
+	},
+	{
+		Key:         "profile",
+		Description: "Workspace profile used by default",
+		get:         func(c *CommonConfig) string { return c.Profile },
+		set: func(c *CommonConfig, value string) error {
+			if nameErr := validateProfileName(value); nameErr != nil {
+				return nameErr
+			}
+			c.Profile = value
+			return nil
+		},
+	},
+	{
+		Key:         "git_protocol",
+		Description: "Protocol used to clone repos",
+		Default:     "https",
+		Allowed:     []string{"https", "ssh"},
+		get:         func(c *CommonConfig) string { return c.GitProtocol },
+		set: func(c *CommonConfig, value string) error {
+			c.GitProtocol = value
+			return nil
+		},
+	},
+	{
+		Key:         "github_host",
+		Description: "GitHub host, set for GitHub Enterprise",
+		Default:     "github.com",
+		get:         func(c *CommonConfig) string { return c.GithubHost },
+		set: func(c *CommonConfig, value string) error {
+			if strings.Contains(value, "/") {
+				return errors.New("expected a host name without scheme or path")
+			}
+			c.GithubHost = value
+			return nil
+		},
+	},
+	{
+		Key:         "parallelism",
+		Description: "Max projects processed at once by parallel commands",
+		Default:     "4",
+		get:         func(c *CommonConfig) string { return intSetting(c.Parallelism) },
+		set: func(c *CommonConfig, value string) (err error) {
+			c.Parallelism, err = parsePositiveInt(value)
+			return
+		},
+	},
+	{
+		Key:         "default_group",
+		Description: "Project group used when no project flags are given",
+		get:         func(c *CommonConfig) string { return c.DefaultGroup },
+		set: func(c *CommonConfig, value string) error {
+			c.DefaultGroup = value
+			return nil
+		},
+	},
+	{
+		Key:         "log_retention_days",
+		Description: "Days to keep logs and reports",
+		Default:     "14",
+		get:         func(c *CommonConfig) string { return intSetting(c.LogRetentionDays) },
+		set: func(c *CommonConfig, value string) (err error) {
+			c.LogRetentionDays, err = parsePositiveInt(value)
+			return
+		},
+	},
+}
+
+func intSetting(value int) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(value)
+}
+
+func parsePositiveInt(value string) (int, error) {
+	parsed, parseErr := strconv.Atoi(value)
+	if parseErr != nil || parsed < 1 {
+		return 0, errors.New("expected a positive integer")
+	}
+
+	return parsed, nil
+}
+
+func findSetting(key string) (Setting, error) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+
+	return Setting{}, fmt.Errorf("unknown setting: %s", key)
+}
+
+func (s Setting) validate(c *CommonConfig, value string) error {
+	if len(s.Allowed) > 0 {
+		allowed := false
+		for _, allowedValue := range s.Allowed {
+			allowed = allowed || allowedValue == value
+		}
+		if !allowed {
+			return fmt.Errorf("%s: expected one of %s", s.Key, strings.Join(s.Allowed, ", "))
+		}
+	}
+
+	if setErr := s.set(c, value); setErr != nil {
+		return fmt.Errorf("%s: %s", s.Key, setErr.Error())
+	}
+
+	return nil
+}
+
+// applySettingDefaults fills unset keys with their defaults
+func applySettingDefaults(c *CommonConfig) {
+	for _, setting := range Settings {
+		if setting.get(c) == "" && setting.Default != "" {
+			_ = setting.set(c, setting.Default)
+		}
+	}
+}
+
+// validateCommonConfig type-checks every set key and every source
+func validateCommonConfig(c CommonConfig) error {
+	for _, setting := range Settings {
+		if value := setting.get(&c); value != "" {
+			if validateErr := setting.validate(&c, value); validateErr != nil {
+				return validateErr
+			}
+		}
+	}
+
+	for _, source := range c.Sources {
+		if validateErr := source.Validate(); validateErr != nil {
+			return validateErr
+		}
+	}
+
+	return nil
+}
+
+// SettingSource reports where the effective value of a key comes from
+func SettingSource(key string) string {
+	if key == "workspace_root" && os.Getenv(envWorkspaceRoot) != "" {
+		return fmt.Sprintf("%s %s", SettingSourceEnv, envWorkspaceRoot)
+	}
+	if key == "workspace_root" && ActiveProfile.WorkspaceRoot != "" {
+		return fmt.Sprintf("%s %s", SettingSourceProfile, ActiveProfileName)
+	}
+	if key == "profile" && profileOverride != "" {
+		return SettingSourceFlag
+	}
+
+	configFile, fileReadErr := ioutil.ReadFile(commonConfigPath())
+	if fileReadErr == nil {
+		var rawConfig map[string]interface{}
+		if json.Unmarshal(configFile, &rawConfig) == nil {
+			if _, inFile := rawConfig[key]; inFile {
+				return SettingSourceFile
+			}
+		}
+	}
+
+	return SettingSourceDefault
+}
+
+// GetSetting returns the effective value of a key
+func GetSetting(key string) (string, error) {
+	setting, settingErr := findSetting(key)
+	if settingErr != nil {
+		return "", settingErr
+	}
+
+	return setting.get(&Config), nil
+}
+
+// SetSetting type-checks a value and writes it to config.json
+func SetSetting(key, value string) error {
+	setting, settingErr := findSetting(key)
+	if settingErr != nil {
+		return settingErr
+	}
+
+	commonConfig, readErr := readCommonConfig()
+	if readErr != nil {
+		return readErr
+	}
+
+	if validateErr := setting.validate(&commonConfig, value); validateErr != nil {
+		return validateErr
+	}
+
+	return installCommonConfig(commonConfig)
+}
+
+// Edit opens config.json in $EDITOR and only saves it once it validates
+func Edit() error {
+	original, readErr := ioutil.ReadFile(commonConfigPath())
+	if readErr != nil {
+		return readErr
+	}
+
+	tempFile, tempErr := ioutil.TempFile("", "pld-config-*.json")
+	if tempErr != nil {
+		return tempErr
+	}
+	defer func() {
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	if _, writeErr := tempFile.Write(original); writeErr != nil {
+		return writeErr
+	}
+	_ = tempFile.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	for {
+		editorSplit := strings.Split(editor, " ")
+		editorCmd := exec.Command(editorSplit[0], append(editorSplit[1:], tempFile.Name())...)
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		if editorErr := editorCmd.Run(); editorErr != nil {
+			return editorErr
+		}
+
+		edited, editedReadErr := ioutil.ReadFile(tempFile.Name())
+		if editedReadErr != nil {
+			return editedReadErr
+		}
+
+		if bytes.Equal(edited, original) {
+			return nil
+		}
+
+		var commonConfig CommonConfig
+		decoder := json.NewDecoder(bytes.NewReader(edited))
+		decoder.DisallowUnknownFields()
+		validateErr := decoder.Decode(&commonConfig)
+		if validateErr == nil {
+			validateErr = validateCommonConfig(commonConfig)
+		}
+
+		if validateErr == nil {
+			return ioutil.WriteFile(commonConfigPath(), edited, os.ModePerm)
+		}
+
+		if !Confirm(fmt.Sprintf("Invalid config (%s), edit again", validateErr.Error())) {
+			return fmt.Errorf("changes discarded: %s", validateErr.Error())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/docker"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	// Folder (inside the config path) holding volume snapshots, one folder per snapshot
+	snapshotsFolder = "snapshots"
+
+	// Snapshot metadata file inside a snapshot folder
+	snapshotMetaFile = "snapshot.json"
+)
+
+// SnapshotConfig holds the settings of a project for volume snapshots
+type SnapshotConfig struct {
+	MigrationVersionCmd []string `json:"migration_version_cmd,omitempty"`
+}
+
+// Snapshot describes the saved volumes of a project
+type Snapshot struct {
+	Name             string               `json:"name"`
+	Project          string               `json:"project"`
+	Branch           string               `json:"branch,omitempty"`
+	Commit           string               `json:"commit,omitempty"`
+	MigrationVersion string               `json:"migration_version,omitempty"`
+	Date             time.Time            `json:"date"`
+	Volumes          []docker.VolumeMount `json:"volumes"`
+	Size             int64                `json:"size"`
+}
+
+func snapshotsPath() string {
+	return filepath.Join(configDir(), snapshotsFolder)
+}
+
+// SnapshotPath is the folder holding a snapshot's metadata and volume archives
+func SnapshotPath(name string) string {
+	return filepath.Join(snapshotsPath(), name)
+}
+
+// VolumeArchivePath is the archive of one volume of a snapshot
+func (s *Snapshot) VolumeArchivePath(volume string) string {
+	return filepath.Join(SnapshotPath(s.Name), volume+".tar.gz")
+}
+
+// NewSnapshot creates the folder of a new snapshot. Names follow the rules of profile names.
+func NewSnapshot(name, projectKey string) (Snapshot, error) {
+	snapshot := Snapshot{Name: name, Project: projectKey, Date: time.Now(), Volumes: []docker.VolumeMount{}}
+
+	if !profileNameRegex.MatchString(name) {
+		return snapshot, fmt.Errorf("invalid snapshot name: %s", name)
+	}
+
+	if _, statErr := os.Stat(SnapshotPath(name)); statErr == nil {
+		return snapshot, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	return snapshot, os.MkdirAll(SnapshotPath(name), os.ModePerm)
+}
+
+// Save writes the snapshot metadata, sizing its archives
+func (s *Snapshot) Save() error {
+	s.Size = 0
+	for _, volume := range s.Volumes {
+		if info, statErr := os.Stat(s.VolumeArchivePath(volume.Name)); statErr == nil {
+			s.Size += info.Size()
+		}
+	}
+
+	snapshotJson, jsonErr := json.MarshalIndent(s, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return ioutil.WriteFile(filepath.Join(SnapshotPath(s.Name), snapshotMetaFile), snapshotJson, os.ModePerm)
+}
+
+// LoadSnapshot reads the metadata of a snapshot by name
+func LoadSnapshot(name string) (Snapshot, error) {
+	var snapshot Snapshot
+
+	if !profileNameRegex.MatchString(name) {
+		return snapshot, fmt.Errorf("invalid snapshot name: %s", name)
+	}
+
+	metaPath := filepath.Join(SnapshotPath(name), snapshotMetaFile)
+	snapshotFile, fileReadErr := ioutil.ReadFile(metaPath)
+	if fileReadErr != nil {
+		if errors.Is(fileReadErr, os.ErrNotExist) {
+			return snapshot, fmt.Errorf("snapshot %s does not exist", name)
+		}
+		return snapshot, fileReadErr
+	}
+
+	if parseErr := json.Unmarshal(snapshotFile, &snapshot); parseErr != nil {
+		return snapshot, fmt.Errorf("%s: %s", metaPath, parseErr.Error())
+	}
+
+	return snapshot, nil
+}
+
+// Snapshots lists every snapshot, newest first. Folders without metadata, left by a failed save,
+// are skipped.
+func Snapshots() ([]Snapshot, error) {
+	snapshots := []Snapshot{}
+
+	snapshotDirs, readDirErr := ioutil.ReadDir(snapshotsPath())
+	if readDirErr != nil {
+		if os.IsNotExist(readDirErr) {
+			return snapshots, nil
+		}
+		return nil, readDirErr
+	}
+
+	for _, snapshotDir := range snapshotDirs {
+		if !snapshotDir.IsDir() {
+			continue
+		}
+
+		snapshot, loadErr := LoadSnapshot(snapshotDir.Name())
+		if loadErr != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Date.After(snapshots[j].Date)
+	})
+
+	return snapshots, nil
+}
+
+// RemoveSnapshot deletes a snapshot with its archives
+func RemoveSnapshot(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name: %s", name)
+	}
+
+	if _, statErr := os.Stat(SnapshotPath(name)); statErr != nil {
+		return fmt.Errorf("snapshot %s does not exist", name)
+	}
+
+	return os.RemoveAll(SnapshotPath(name))
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/poloniex/polo-local-dev/git"
+	"github.com/poloniex/polo-local-dev/output"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SourceTypeEmbedded = "embedded"
+	SourceTypeDir      = "dir"
+	SourceTypeGit      = "git"
+)
+
+var (
+	// Folder (inside the config path) caching git config sources
+	sourcesFolder = "sources"
+
+	// Used when no sources are configured
+	defaultSources = []Source{{Name: SourceDist, Type: SourceTypeEmbedded}}
+
+	// Source name of each dist project, as loaded
+	distProjectSources = map[string]string{}
+)
+
+// Source is a location project configs are loaded from
+type Source struct {
+	Name   string `json:"name,omitempty"`
+	Type   string `json:"type"`
+	Path   string `json:"path,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+func configuredSources() []Source {
+	if len(Config.Sources) == 0 {
+		return defaultSources
+	}
+
+	return Config.Sources
+}
+
+// SourceName is the configured name, or one derived from the location
+func (s Source) SourceName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	switch s.Type {
+	case SourceTypeDir:
+		return filepath.Base(absolutePath(s.Path))
+	case SourceTypeGit:
+		return strings.TrimSuffix(path.Base(s.URL), ".git")
+	}
+
+	return SourceDist
+}
+
+func (s Source) branch() string {
+	if s.Branch != "" {
+		return s.Branch
+	}
+
+	return "master"
+}
+
+func (s Source) cachePath() string {
+	return filepath.Join(configDir(), sourcesFolder, s.SourceName())
+}
+
+func (s Source) Validate() error {
+	switch s.Type {
+	case SourceTypeEmbedded:
+		return nil
+	case SourceTypeDir:
+		if s.Path == "" {
+			return fmt.Errorf("source %s: path is required", s.SourceName())
+		}
+		return nil
+	case SourceTypeGit:
+		if s.URL == "" {
+			return fmt.Errorf("source %s: url is required", s.SourceName())
+		}
+		return nil
+	}
+
+	return fmt.Errorf("source %s: unknown type %s", s.SourceName(), s.Type)
+}
+
+// fs opens the source as a filesystem, cloning git sources that are not cached yet
+func (s Source) fs() (fs.FS, error) {
+	switch s.Type {
+	case SourceTypeEmbedded:
+		return fs.Sub(distEmbed, "dist")
+
+	case SourceTypeDir:
+		return os.DirFS(absolutePath(s.Path)), nil
+
+	case SourceTypeGit:
+		if _, statErr := os.Stat(s.cachePath()); errors.Is(statErr, os.ErrNotExist) {
+			output.Plain(fmt.Sprintf("Fetching config source: %s", s.SourceName()))
+			if cloneErr := git.CloneBranch(s.cachePath(), s.URL, s.branch()); cloneErr != nil {
+				_ = os.RemoveAll(s.cachePath())
+				return nil, fmt.Errorf("source %s: %s", s.SourceName(), cloneErr.Error())
+			}
+		}
+
+		root := s.cachePath()
+		if s.Path != "" {
+			root = filepath.Join(root, s.Path)
+		}
+		return os.DirFS(root), nil
+	}
+
+	return nil, s.Validate()
+}
+
+// update pulls the latest revision of git sources
+func (s Source) update() error {
+	if s.Type != SourceTypeGit {
+		return nil
+	}
+
+	if _, statErr := os.Stat(s.cachePath()); errors.Is(statErr, os.ErrNotExist) {
+		_, fsErr := s.fs()
+		return fsErr
+	}
+
+	if syncErr := git.SyncBranch(s.cachePath(), s.branch()); syncErr != nil {
+		return fmt.Errorf("source %s: %s", s.SourceName(), syncErr.Error())
+	}
+
+	return nil
+}
+
+// loadSourceConfigs reads every *.project.json at the root of a source
+func loadSourceConfigs(source Source) (map[string]Project, error) {
+	sourceConfigs := map[string]Project{}
+
+	if validateErr := source.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+
+	sourceFs, fsErr := source.fs()
+	if fsErr != nil {
+		return nil, fsErr
+	}
+
+	sourceFiles, readDirErr := fs.ReadDir(sourceFs, ".")
+	if readDirErr != nil {
+		return nil, fmt.Errorf("source %s: %s", source.SourceName(), readDirErr.Error())
+	}
+
+	for _, sourceFilename := range sourceFiles {
+
+		// Only parse *.project.json files
+		if sourceFilename.IsDir() || !strings.Contains(sourceFilename.Name(), ".project.json") {
+			continue
+		}
+
+		// Read file contents
+		sourceFile, fileReadErr := fs.ReadFile(sourceFs, sourceFilename.Name())
+		if fileReadErr != nil {
+			return nil, fileReadErr
+		}
+
+		// Parse into ProjectFile, templates and group settings
+		sourceConfigFile, parseErr := parseProjectFile(sourceFile)
+		if parseErr != nil {
+			return nil, fmt.Errorf("source %s: %s: %s", source.SourceName(), sourceFilename.Name(), parseErr.Error())
+		}
+		sourceConfigFile.register()
+		sourceConfig := sourceConfigFile.projects
+
+		// Append to returned set
+		for projectName, project := range sourceConfig {
+			sourceConfigs[projectName] = project
+		}
+	}
+
+	return sourceConfigs, nil
+}
+
+// Update pulls the latest revision of every git source and refreshes the dist configs
+func Update() error {
+	for _, source := range configuredSources() {
+		if updateErr := source.update(); updateErr != nil {
+			return updateErr
+		}
+		output.Ok(fmt.Sprintf("Source up to date: %s", source.SourceName()))
+	}
+
+	distConfigs, distSources, distLoadErr := loadDistConfigs()
+	if distLoadErr != nil {
+		return distLoadErr
+	}
+
+	distProjectConfigs = distConfigs
+	distProjectSources = distSources
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pushProjectFile commits a project file to a working copy and pushes it to its bare origin
+func pushProjectFile(t *testing.T, work *gogit.Repository, workDir, name, content string) {
+	t.Helper()
+
+	if writeErr := ioutil.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	worktree, worktreeErr := work.Worktree()
+	if worktreeErr != nil {
+		t.Fatal(worktreeErr)
+	}
+	if _, addErr := worktree.Add(name); addErr != nil {
+		t.Fatal(addErr)
+	}
+
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, commitErr := worktree.Commit("add "+name, &gogit.CommitOptions{Author: signature}); commitErr != nil {
+		t.Fatal(commitErr)
+	}
+
+	pushErr := work.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/master"},
+	})
+	if pushErr != nil && pushErr != gogit.NoErrAlreadyUpToDate {
+		t.Fatal(pushErr)
+	}
+}
+
+func TestGitSourceCloneAndUpdate(t *testing.T) {
+	t.Setenv(envHome, t.TempDir())
+
+	bareDir := filepath.Join(t.TempDir(), "team-configs.git")
+	if _, initErr := gogit.PlainInit(bareDir, true); initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	workDir := t.TempDir()
+	work, initErr := gogit.PlainInit(workDir, false)
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	if _, remoteErr := work.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{bareDir}}); remoteErr != nil {
+		t.Fatal(remoteErr)
+	}
+	pushProjectFile(t, work, workDir, "alpha.project.json", `{"alpha": {"repo": "alpha", "name": "alpha"}}`)
+
+	source := Source{Type: SourceTypeGit, URL: bareDir}
+	if name := source.SourceName(); name != "team-configs" {
+		t.Fatalf("source name = %q, want %q", name, "team-configs")
+	}
+
+	// First load clones into the sources cache
+	sourceConfigs, loadErr := loadSourceConfigs(source)
+	if loadErr != nil {
+		t.Fatalf("load: %s", loadErr)
+	}
+	if _, loaded := sourceConfigs["alpha"]; !loaded || len(sourceConfigs) != 1 {
+		t.Fatalf("loaded %v, want alpha only", sourceConfigs)
+	}
+
+	pushProjectFile(t, work, workDir, "beta.project.json", `{"beta": {"repo": "beta", "name": "beta"}}`)
+
+	// The cache is only refreshed by an update
+	sourceConfigs, loadErr = loadSourceConfigs(source)
+	if loadErr != nil {
+		t.Fatalf("reload: %s", loadErr)
+	}
+	if _, loaded := sourceConfigs["beta"]; loaded {
+		t.Fatal("beta loaded before update")
+	}
+
+	if updateErr := source.update(); updateErr != nil {
+		t.Fatalf("update: %s", updateErr)
+	}
+	sourceConfigs, loadErr = loadSourceConfigs(source)
+	if loadErr != nil {
+		t.Fatalf("load after update: %s", loadErr)
+	}
+	for _, projectKey := range []string{"alpha", "beta"} {
+		if _, loaded := sourceConfigs[projectKey]; !loaded {
+			t.Fatalf("%s not loaded after update", projectKey)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Reserved keys in project files
+	fileKeyDefaults  = "defaults"
+	fileKeyTemplates = "templates"
+	fileKeyGroups    = "groups"
+	fileKeyNetworks  = "networks"
+)
+
+var (
+	// Named project templates collected from every project file, later files taking precedence
+	projectTemplates = map[string]rawProject{}
+)
+
+// parsedProjectFile is a project file split into its projects and reserved sections
+type parsedProjectFile struct {
+	projects  ProjectFile
+	templates map[string]rawProject
+	groups    map[string]GroupConfig
+	networks  map[string]NetworkConfig
+}
+
+// register makes the file's templates, group settings and networks available to every project
+func (f parsedProjectFile) register() {
+	for templateName, template := range f.templates {
+		projectTemplates[templateName] = template
+	}
+
+	for groupName, group := range f.groups {
+		GroupConfigs[groupName] = group
+	}
+
+	for networkName, network := range f.networks {
+		NetworkConfigs[networkName] = network
+	}
+}
+
+// parseProjectFile splits a project file into its projects, templates, group settings and networks.
+// File-level defaults are applied to every project in the file, with the project's own fields
+// taking precedence.
+func parseProjectFile(fileBytes []byte) (parsedProjectFile, error) {
+	parsed := parsedProjectFile{
+		projects:  ProjectFile{},
+		templates: map[string]rawProject{},
+		groups:    map[string]GroupConfig{},
+		networks:  map[string]NetworkConfig{},
+	}
+
+	var rawFile map[string]json.RawMessage
+	if parseErr := json.Unmarshal(fileBytes, &rawFile); parseErr != nil {
+		return parsed, parseErr
+	}
+
+	defaults := rawProject{}
+	if rawDefaults, exists := rawFile[fileKeyDefaults]; exists {
+		if parseErr := json.Unmarshal(rawDefaults, &defaults); parseErr != nil {
+			return parsed, fmt.Errorf("%s: %s", fileKeyDefaults, parseErr.Error())
+		}
+		delete(rawFile, fileKeyDefaults)
+	}
+
+	if rawTemplates, exists := rawFile[fileKeyTemplates]; exists {
+		if parseErr := json.Unmarshal(rawTemplates, &parsed.templates); parseErr != nil {
+			return parsed, fmt.Errorf("%s: %s", fileKeyTemplates, parseErr.Error())
+		}
+		delete(rawFile, fileKeyTemplates)
+	}
+
+	if rawGroups, exists := rawFile[fileKeyGroups]; exists {
+		if parseErr := json.Unmarshal(rawGroups, &parsed.groups); parseErr != nil {
+			return parsed, fmt.Errorf("%s: %s", fileKeyGroups, parseErr.Error())
+		}
+		delete(rawFile, fileKeyGroups)
+	}
+
+	if rawNetworks, exists := rawFile[fileKeyNetworks]; exists {
+		if parseErr := json.Unmarshal(rawNetworks, &parsed.networks); parseErr != nil {
+			return parsed, fmt.Errorf("%s: %s", fileKeyNetworks, parseErr.Error())
+		}
+		delete(rawFile, fileKeyNetworks)
+	}
+
+	for projectKey, rawProjectJson := range rawFile {
+		var raw rawProject
+		if parseErr := json.Unmarshal(rawProjectJson, &raw); parseErr != nil {
+			return parsed, fmt.Errorf("%s: %s", projectKey, parseErr.Error())
+		}
+
+		merged, mergeErr := mergeRaw(defaults, raw)
+		if mergeErr != nil {
+			return parsed, fmt.Errorf("%s: %s", projectKey, mergeErr.Error())
+		}
+
+		project, projectErr := fromRaw(merged)
+		if projectErr != nil {
+			return parsed, fmt.Errorf("%s: %s", projectKey, projectErr.Error())
+		}
+
+		parsed.projects[projectKey] = project
+	}
+
+	return parsed, nil
+}
+
+// resolveTemplate flattens a template and the templates it extends
+func resolveTemplate(name string, chain []string) (rawProject, error) {
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("template cycle: %s", strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	template, exists := projectTemplates[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown template: %s", name)
+	}
+
+	parentName, hasParent := template["extends"].(string)
+	if !hasParent || parentName == "" {
+		return template, nil
+	}
+
+	parent, parentErr := resolveTemplate(parentName, append(chain, name))
+	if parentErr != nil {
+		return nil, parentErr
+	}
+
+	return mergeRaw(parent, template)
+}
+
+// resolveProject applies the template a project extends. Fields set on the project replace the
+// template's.
+func resolveProject(project Project) (Project, error) {
+	if project.Extends == "" {
+		return project, nil
+	}
+
+	template, templateErr := resolveTemplate(project.Extends, nil)
+	if templateErr != nil {
+		return project, templateErr
+	}
+
+	raw, rawErr := toRaw(project)
+	if rawErr != nil {
+		return project, rawErr
+	}
+
+	merged, mergeErr := mergeRaw(template, raw)
+	if mergeErr != nil {
+		return project, mergeErr
+	}
+
+	return fromRaw(merged)
+}
+
+// resolveProjects applies templates to a set of projects, leaving unresolvable ones as they are
+func resolveProjects(projects map[string]Project) (map[string]Project, []error) {
+	resolved := map[string]Project{}
+	var errs []error
+
+	for projectKey, project := range projects {
+		resolvedProject, resolveErr := resolveProject(project)
+		if resolveErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", projectKey, resolveErr.Error()))
+		}
+		resolved[projectKey] = resolvedProject
+	}
+
+	return resolved, errs
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"github.com/poloniex/polo-local-dev/git"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Max passes when expanding variables that reference other variables
+	maxExpansionDepth = 10
+)
+
+var (
+	// Matches #NAME# style placeholders
+	placeholderRegex = regexp.MustCompile(`#([A-Z][A-Z0-9_]*)#`)
+
+	// Matches ${ENV_VAR} and ${ENV_VAR:-default}
+	envRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+	// GroupConfigs are group-level settings collected from every project file
+	GroupConfigs = map[string]GroupConfig{}
+)
+
+// GroupConfig holds settings shared by every project in a group
+type GroupConfig struct {
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// userVariables merges user-defined variables: global, then each group in order, then the project,
+// then the active profile
+func (p *Project) userVariables() map[string]string {
+	variables := map[string]string{}
+
+	for name, value := range Config.Variables {
+		variables[name] = value
+	}
+
+	for _, group := range p.Groups {
+		for name, value := range GroupConfigs[group].Variables {
+			variables[name] = value
+		}
+	}
+
+	for name, value := range p.Variables {
+		variables[name] = value
+	}
+
+	for name, value := range ActiveProfile.Variables {
+		variables[name] = value
+	}
+
+	return variables
+}
+
+// expand resolves environment references and placeholders in a command template. Git placeholders
+// are only looked up when resolveGit is set, so configs can be validated without touching repos.
+func (p *Project) expand(template string, resolveGit bool) (string, error) {
+	builtins := p.stringReplacements()
+	variables := p.userVariables()
+	gitValues := map[string]string{}
+
+	expanded := template
+	for pass := 0; pass < maxExpansionDepth; pass++ {
+		var expandErr error
+
+		// ${ENV_VAR} and ${ENV_VAR:-default}
+		next := envRegex.ReplaceAllStringFunc(expanded, func(match string) string {
+			parts := envRegex.FindStringSubmatch(match)
+			if value, isSet := lookupEnv(parts[1]); isSet && (value != "" || parts[2] == "") {
+				return value
+			}
+			if parts[2] != "" {
+				return parts[3]
+			}
+			expandErr = fmt.Errorf("environment variable %s is not set", parts[1])
+			return match
+		})
+
+		// #PLACEHOLDER#
+		next = placeholderRegex.ReplaceAllStringFunc(next, func(match string) string {
+			if value, isBuiltin := builtins[match]; isBuiltin {
+				return value
+			}
+
+			name := placeholderRegex.FindStringSubmatch(match)[1]
+			if name == "GIT_BRANCH" || name == "GIT_SHA" {
+				if !resolveGit {
+					return "git"
+				}
+				if len(gitValues) == 0 {
+					branch, sha, headErr := git.Head(p.RootPath())
+					if headErr != nil {
+						expandErr = fmt.Errorf("%s: %s", match, headErr.Error())
+						return match
+					}
+					gitValues["GIT_BRANCH"] = branch
+					gitValues["GIT_SHA"] = sha
+				}
+				return gitValues[name]
+			}
+
+			if value, isVariable := variables[name]; isVariable {
+				return value
+			}
+
+			return match
+		})
+
+		if expandErr != nil {
+			return expanded, expandErr
+		}
+
+		if next == expanded {
+			break
+		}
+		expanded = next
+	}
+
+	if unresolved := placeholderRegex.FindAllString(expanded, -1); len(unresolved) > 0 {
+		return expanded, fmt.Errorf("unresolved placeholder %s", strings.Join(unresolved, ", "))
+	}
+
+	return expanded, nil
+}
+
+// Validate checks that every command template of the project resolves
+func (p *Project) Validate() []error {
+	var errs []error
+
+	for name := range p.userVariables() {
+		if _, isBuiltin := p.stringReplacements()["#"+name+"#"]; isBuiltin {
+			errs = append(errs, fmt.Errorf("variable %s shadows a built-in placeholder", name))
+		}
+	}
+
+	switch p.Type {
+	case "", ProjectTypeShell:
+	case ProjectTypeCompose:
+		if len(p.BuildCmd) > 0 || len(p.RunCmd) > 0 {
+			errs = append(errs, fmt.Errorf("compose projects do not use build_cmd or run_cmd"))
+		}
+		if p.Compose != nil {
+			if _, expandErr := p.expand(p.Compose.File, false); expandErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", p.Compose.File, expandErr.Error()))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown project type %s", p.Type))
+	}
+
+	switch p.Restart {
+	case "", RestartNo, RestartOnFailure, RestartAlways:
+	default:
+		errs = append(errs, fmt.Errorf("unknown restart policy %s", p.Restart))
+	}
+
+	if p.Readiness != nil {
+		errs = append(errs, p.Readiness.Validate()...)
+
+		if p.Readiness.HTTP != nil {
+			if _, expandErr := p.expand(p.Readiness.HTTP.URL, false); expandErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", p.Readiness.HTTP.URL, expandErr.Error()))
+			}
+		}
+	}
+
+	errs = append(errs, p.validatePhases()...)
+	errs = append(errs, p.validatePorts()...)
+
+	commands := append(append(append(append([]ShellCommand{}, p.BuildCmd...), p.RunCmd...), p.TestCmd...), p.Hooks.all()...)
+	for _, definition := range p.Phases {
+		commands = append(commands, definition.Cmd...)
+	}
+
+	for _, report := range p.TestReports {
+		if _, expandErr := p.expand(report, false); expandErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", report, expandErr.Error()))
+		}
+	}
+
+	for _, cmd := range commands {
+		for _, template := range []string{cmd.Command, cmd.Path} {
+			if _, expandErr := p.expand(template, false); expandErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", template, expandErr.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateProjects validates every loaded project, keyed by project key
+func ValidateProjects() map[string][]error {
+	invalid := map[string][]error{}
+
+	for projectKey, project := range ProjectConfigs {
+		if errs := project.Validate(); len(errs) > 0 {
+			invalid[projectKey] = errs
+		}
+	}
+
+	return invalid
+}
@@ -0,0 +1,70 @@
+package config
+
+import "testing"
+
+func TestExpand(t *testing.T) {
+	Config = CommonConfig{WorkspaceRoot: "/work", Variables: map[string]string{"GLOBAL": "global", "LAYERED": "global"}}
+	GroupConfigs = map[string]GroupConfig{"backend": {Variables: map[string]string{"LAYERED": "group", "GROUP": "group"}}}
+	ActiveProfile = Profile{Env: map[string]string{"PLD_TEST_PROFILE_ENV": "profile"}}
+	defer func() {
+		Config = CommonConfig{}
+		GroupConfigs = map[string]GroupConfig{}
+		ActiveProfile = Profile{}
+	}()
+	t.Setenv("PLD_TEST_ENV", "env")
+	t.Setenv("PLD_TEST_EMPTY", "")
+
+	project := Project{
+		Repo:      "users",
+		Name:      "users-api",
+		Groups:    []string{"backend"},
+		Variables: map[string]string{"PORT": "8080", "URL": "http://#NAME#:#PORT#", "PROJECT": "project"},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "#NAME# in #REPO#", want: "users-api in users"},
+		{template: "cd #PROJECT_ROOT#", want: "cd /work/users"},
+		{template: "#WORKSPACE_ROOT#/shared", want: "/work/shared"},
+		{template: "#GLOBAL# #GROUP# #PROJECT#", want: "global group project"},
+		{template: "#LAYERED#", want: "group"},
+		{template: "curl #URL#", want: "curl http://users-api:8080"},
+		{template: "${PLD_TEST_ENV}", want: "env"},
+		{template: "${PLD_TEST_PROFILE_ENV}", want: "profile"},
+		{template: "${PLD_TEST_UNSET:-fallback}", want: "fallback"},
+		{template: "${PLD_TEST_EMPTY:-fallback}", want: "fallback"},
+		{template: "${PLD_TEST_EMPTY}", want: ""},
+		{template: "${PLD_TEST_UNSET:-#NAME#}", want: "users-api"},
+		{template: "#GIT_BRANCH#", want: "git"},
+		{template: "${PLD_TEST_UNSET}", wantErr: true},
+		{template: "#UNKNOWN#", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			expanded, expandErr := project.expand(test.template, false)
+			if test.wantErr {
+				if expandErr == nil {
+					t.Fatalf("expanded to %q, want an error", expanded)
+				}
+				return
+			}
+			if expandErr != nil {
+				t.Fatal(expandErr)
+			}
+			if expanded != test.want {
+				t.Fatalf("expanded to %q, want %q", expanded, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandVariableCycle(t *testing.T) {
+	project := Project{Variables: map[string]string{"A": "#B#", "B": "#A#"}}
+	if expanded, expandErr := project.expand("#A#", false); expandErr == nil {
+		t.Fatalf("expanded to %q, want an error", expanded)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/poloniex/polo-local-dev/docker"
+	"sort"
+)
+
+const (
+	// Restart policies, applied by pld watch to containers that die
+	RestartNo        = "no"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// ShouldRestart reports whether the restart policy restarts a container that died with the exit code
+func (p *Project) ShouldRestart(exitCode int) bool {
+	switch p.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	}
+
+	return false
+}
+
+// ContainerMatcher recognises the containers of the project without guessing. Compose projects
+// match their service labels, other projects the service and project labels or the container names
+// of the compose project named after the repo. Looser matches on the service name alone could be
+// any stack's container, FindRunningContainer lets the user choose among those.
+func (p *Project) ContainerMatcher() (func(types.Container) bool, error) {
+	if p.IsCompose() {
+		ref, refErr := p.ServiceRef()
+		if refErr != nil {
+			return nil, refErr
+		}
+
+		return func(container types.Container) bool {
+			return container.Labels[docker.LabelProject] == ref.Project && container.Labels[docker.LabelService] == ref.Service
+		}, nil
+	}
+
+	return anyMatcher(p.preciseContainerMatchers()), nil
+}
+
+// FallbackContainerMatcher recognises the containers only the looser matches of
+// FindRunningContainer find: the service label alone or the name in any compose project
+func (p *Project) FallbackContainerMatcher() (func(types.Container) bool, error) {
+	precise, matcherErr := p.ContainerMatcher()
+	if matcherErr != nil {
+		return nil, matcherErr
+	}
+	if p.IsCompose() {
+		return func(types.Container) bool { return false }, nil
+	}
+
+	loose := anyMatcher(p.containerMatchers())
+
+	return func(container types.Container) bool {
+		return loose(container) && !precise(container)
+	}, nil
+}
+
+func anyMatcher(matchers []func(types.Container) bool) func(types.Container) bool {
+	return func(container types.Container) bool {
+		for _, matcher := range matchers {
+			if matcher(container) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RunDependents lists the projects that need a project to run, directly or through other projects,
+// in the order to start them
+func RunDependents(projectKey string) []string {
+	// Built apart from the reverse graph, which only covers the projects of the current selection
+	dependentKeys := make([]string, 0, len(ProjectConfigs))
+	for dependentKey := range ProjectConfigs {
+		dependentKeys = append(dependentKeys, dependentKey)
+	}
+	sort.Strings(dependentKeys)
+
+	runDependents := map[string][]string{}
+	for _, dependentKey := range dependentKeys {
+		for _, dependency := range ProjectConfigs[dependentKey].DependsOn.Run {
+			runDependents[dependency] = append(runDependents[dependency], dependentKey)
+		}
+	}
+
+	dependents := map[string]bool{}
+	pending := []string{projectKey}
+	for len(pending) > 0 {
+		parent := pending[0]
+		pending = pending[1:]
+		for _, child := range runDependents[parent] {
+			if !dependents[child] && child != projectKey {
+				dependents[child] = true
+				pending = append(pending, child)
+			}
+		}
+	}
+
+	// Only dependencies among the dependents matter, the others are running already
+	ordered := []string{}
+	placed := map[string]bool{}
+	for len(ordered) < len(dependents) {
+		progressed := false
+		for _, dependent := range sortedSet(dependents) {
+			if placed[dependent] {
+				continue
+			}
+
+			ready := true
+			for _, dependency := range GetProjectByKey(dependent).DependsOn.Run {
+				if dependents[dependency] && !placed[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				ordered = append(ordered, dependent)
+				placed[dependent] = true
+				progressed = true
+			}
+		}
+
+		// A dependency cycle, start the rest as they come
+		if !progressed {
+			for _, dependent := range sortedSet(dependents) {
+				if !placed[dependent] {
+					ordered = append(ordered, dependent)
+					placed[dependent] = true
+				}
+			}
+		}
+	}
+
+	return ordered
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ContainerProject is the key of the project a container belongs to, empty when it belongs to none.
+// Only the precise matches of ContainerMatcher count, a mysql container of another stack is nobody's.
+func ContainerProject(container types.Container) string {
+	for _, projectKey := range sortedProjectKeys(ProjectConfigs) {
+		project := ProjectConfigs[projectKey]
+		if matches, matcherErr := project.ContainerMatcher(); matcherErr == nil && matches(container) {
+			return projectKey
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunDependents(t *testing.T) {
+	savedConfigs := ProjectConfigs
+	defer func() { ProjectConfigs = savedConfigs }()
+
+	// Dependencies come before their dependents, ties in key order, and a cycle closes the list
+	ProjectConfigs = map[string]Project{
+		"db":     {Name: "db"},
+		"cache":  {Name: "cache"},
+		"api":    {Name: "api", DependsOn: DependsOn{Run: []string{"db", "cache"}}},
+		"worker": {Name: "worker", DependsOn: DependsOn{Run: []string{"db"}}},
+		"web":    {Name: "web", DependsOn: DependsOn{Run: []string{"worker", "api"}}},
+		"admin":  {Name: "admin", DependsOn: DependsOn{Run: []string{"api"}, Compile: []string{"web"}}},
+		"loopa":  {Name: "loopa", DependsOn: DependsOn{Run: []string{"loopb", "db"}}},
+		"loopb":  {Name: "loopb", DependsOn: DependsOn{Run: []string{"loopa"}}},
+	}
+
+	tests := []struct {
+		projectKey string
+		want       string
+	}{
+		{projectKey: "db", want: "api|worker|admin|web|loopa|loopb"},
+		{projectKey: "cache", want: "api|web|admin"},
+		{projectKey: "worker", want: "web"},
+		{projectKey: "web", want: ""},
+		{projectKey: "loopa", want: "loopb"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.projectKey, func(t *testing.T) {
+			if got := strings.Join(RunDependents(test.projectKey), "|"); got != test.want {
+				t.Fatalf("dependents %s, want %s", got, test.want)
+			}
+		})
+	}
+}
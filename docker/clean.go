@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+)
+
+// AllContainers lists every container, stopped ones included
+func AllContainers(ctx context.Context) ([]types.Container, error) {
+	return dockerClient.ContainerList(ctx, types.ContainerListOptions{All: true})
+}
+
+// ContainerVolumes lists the named volumes mounted by a container
+func ContainerVolumes(container types.Container) []string {
+	volumes := []string{}
+	for _, mount := range container.Mounts {
+		if mount.Type == "volume" && mount.Name != "" {
+			volumes = append(volumes, mount.Name)
+		}
+	}
+
+	return volumes
+}
+
+// RemoveContainer removes a container, stopping it first when it runs. Its volumes are kept.
+func RemoveContainer(ctx context.Context, containerID string) error {
+	return dockerClient.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{Force: true})
+}
+
+// RemoveVolume removes a volume no container uses
+func RemoveVolume(ctx context.Context, name string) error {
+	return dockerClient.VolumeRemove(ctx, name, false)
+}
+
+// RemoveImage removes an image with its untagged parents
+func RemoveImage(ctx context.Context, imageID string) error {
+	_, removeErr := dockerClient.ImageRemove(ctx, imageID, types.ImageRemoveOptions{PruneChildren: true})
+	return removeErr
+}
@@ -0,0 +1,542 @@
+package docker
+
+import (
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// Files docker compose looks for when none is given, in order of preference
+	ComposeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}
+
+	// Service keys the docker API runner applies, anything else would be silently ignored
+	composeServiceKeys = map[string]bool{
+		"image": true, "container_name": true, "build": true, "depends_on": true, "healthcheck": true,
+		"environment": true, "env_file": true, "ports": true, "volumes": true, "networks": true,
+		"command": true, "labels": true,
+	}
+)
+
+// ComposeFile is the subset of a compose file pld understands
+type ComposeFile struct {
+	Path     string                    `yaml:"-"`
+	Name     string                    `yaml:"name"`
+	Services map[string]ComposeService `yaml:"services"`
+	Networks map[string]ComposeNetwork `yaml:"networks"`
+}
+
+// ComposeNetwork is a top-level network of a compose file
+type ComposeNetwork struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+func (n *ComposeNetwork) UnmarshalYAML(node *yaml.Node) error {
+	var network struct {
+		Name     string    `yaml:"name"`
+		External yaml.Node `yaml:"external"`
+	}
+	if decodeErr := node.Decode(&network); decodeErr != nil {
+		return decodeErr
+	}
+	n.Name = network.Name
+
+	// Older files name external networks with external.name
+	switch network.External.Kind {
+	case yaml.ScalarNode:
+		return network.External.Decode(&n.External)
+	case yaml.MappingNode:
+		var external struct {
+			Name string `yaml:"name"`
+		}
+		if decodeErr := network.External.Decode(&external); decodeErr != nil {
+			return decodeErr
+		}
+		n.External = true
+		if external.Name != "" {
+			n.Name = external.Name
+		}
+	}
+
+	return nil
+}
+
+type ComposeService struct {
+	Image         string             `yaml:"image"`
+	ContainerName string             `yaml:"container_name"`
+	Build         *ComposeBuild      `yaml:"build"`
+	DependsOn     ComposeDependsOn   `yaml:"depends_on"`
+	Healthcheck   *ComposeHealth     `yaml:"healthcheck"`
+	Environment   ComposeEnvironment `yaml:"environment"`
+	EnvFile       ComposeEnvFiles    `yaml:"env_file"`
+	Ports         []string           `yaml:"ports"`
+	Volumes       ComposeVolumes     `yaml:"volumes"`
+	Networks      ComposeNetworks    `yaml:"networks"`
+	Command       ComposeCommand     `yaml:"command"`
+	Labels        ComposeEnvironment `yaml:"labels"`
+
+	// Keys of the service pld does not support, in file order
+	unsupported []string
+}
+
+func (s *ComposeService) UnmarshalYAML(node *yaml.Node) error {
+	type plain ComposeService
+	if decodeErr := node.Decode((*plain)(s)); decodeErr != nil {
+		return decodeErr
+	}
+
+	s.unsupported = nil
+	for _, key := range mappingKeys(node) {
+		// x- keys are extensions compose itself ignores
+		if !composeServiceKeys[key] && !strings.HasPrefix(key, "x-") {
+			s.unsupported = append(s.unsupported, key)
+		}
+	}
+
+	return nil
+}
+
+// mappingKeys lists the keys of a mapping, following << merge keys into the mappings they merge
+func mappingKeys(node *yaml.Node) []string {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	keys := []string{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		if key != "<<" {
+			keys = append(keys, key)
+			continue
+		}
+
+		merged := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			merged = value.Content
+		}
+		for _, mapping := range merged {
+			keys = append(keys, mappingKeys(mapping)...)
+		}
+	}
+
+	return keys
+}
+
+// Supported fails on the first key of the service pld cannot run it with
+func (s *ComposeService) Supported() error {
+	if len(s.unsupported) > 0 {
+		return fmt.Errorf("unsupported compose key %s", s.unsupported[0])
+	}
+
+	return nil
+}
+
+// ComposeBuild is either a context path or a build section
+type ComposeBuild struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	Target     string            `yaml:"target"`
+	Args       map[string]string `yaml:"args"`
+}
+
+func (b *ComposeBuild) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Context = node.Value
+		return nil
+	}
+
+	type plain ComposeBuild
+	return node.Decode((*plain)(b))
+}
+
+type ComposeHealth struct {
+	Test        ComposeCommand `yaml:"test"`
+	Interval    string         `yaml:"interval"`
+	Timeout     string         `yaml:"timeout"`
+	StartPeriod string         `yaml:"start_period"`
+	Retries     int            `yaml:"retries"`
+	Disable     bool           `yaml:"disable"`
+}
+
+// ComposeDependsOn is either a list of services or a map of services to conditions
+type ComposeDependsOn []string
+
+func (d *ComposeDependsOn) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var services []string
+		if decodeErr := node.Decode(&services); decodeErr != nil {
+			return decodeErr
+		}
+		*d = services
+		return nil
+	}
+
+	var conditions map[string]interface{}
+	if decodeErr := node.Decode(&conditions); decodeErr != nil {
+		return decodeErr
+	}
+
+	services := make([]string, 0, len(conditions))
+	for service := range conditions {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	*d = services
+
+	return nil
+}
+
+// ComposeEnvironment is either a list of KEY=value or a map
+type ComposeEnvironment map[string]string
+
+func (e *ComposeEnvironment) UnmarshalYAML(node *yaml.Node) error {
+	environment := map[string]string{}
+
+	if node.Kind == yaml.SequenceNode {
+		var entries []string
+		if decodeErr := node.Decode(&entries); decodeErr != nil {
+			return decodeErr
+		}
+		for _, entry := range entries {
+			key, value := splitEnvEntry(entry)
+			environment[key] = value
+		}
+		*e = environment
+		return nil
+	}
+
+	var values map[string]*string
+	if decodeErr := node.Decode(&values); decodeErr != nil {
+		return decodeErr
+	}
+	for key, value := range values {
+		if value != nil {
+			environment[key] = *value
+		} else {
+			environment[key] = ""
+		}
+	}
+	*e = environment
+
+	return nil
+}
+
+// ComposeEnvFile is a file of KEY=value lines loaded into the environment of a service
+type ComposeEnvFile struct {
+	Path     string `yaml:"path"`
+	Required bool   `yaml:"required"`
+}
+
+// ComposeEnvFiles is a path, a list of paths or a list of path and required settings. Files are
+// required unless marked otherwise.
+type ComposeEnvFiles []ComposeEnvFile
+
+func (f *ComposeEnvFiles) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*f = ComposeEnvFiles{{Path: node.Value, Required: true}}
+		return nil
+	}
+
+	envFiles := ComposeEnvFiles{}
+	for _, entry := range node.Content {
+		if entry.Kind == yaml.ScalarNode {
+			envFiles = append(envFiles, ComposeEnvFile{Path: entry.Value, Required: true})
+			continue
+		}
+
+		envFile := ComposeEnvFile{Required: true}
+		if decodeErr := entry.Decode(&envFile); decodeErr != nil {
+			return decodeErr
+		}
+		envFiles = append(envFiles, envFile)
+	}
+	*f = envFiles
+
+	return nil
+}
+
+// ComposeNetworks is either a list of networks or a map of networks to settings
+type ComposeNetworks []string
+
+func (n *ComposeNetworks) UnmarshalYAML(node *yaml.Node) error {
+	var dependsOn ComposeDependsOn
+	if decodeErr := dependsOn.UnmarshalYAML(node); decodeErr != nil {
+		return decodeErr
+	}
+	*n = ComposeNetworks(dependsOn)
+
+	return nil
+}
+
+// ComposeVolumes are volume mounts in short syntax, source:target[:mode]. Long syntax entries are
+// converted to short syntax.
+type ComposeVolumes []string
+
+func (v *ComposeVolumes) UnmarshalYAML(node *yaml.Node) error {
+	volumes := []string{}
+
+	for _, entry := range node.Content {
+		if entry.Kind == yaml.ScalarNode {
+			volumes = append(volumes, entry.Value)
+			continue
+		}
+
+		var mount struct {
+			Source   string `yaml:"source"`
+			Target   string `yaml:"target"`
+			ReadOnly bool   `yaml:"read_only"`
+		}
+		if decodeErr := entry.Decode(&mount); decodeErr != nil {
+			return decodeErr
+		}
+
+		volume := mount.Target
+		if mount.Source != "" {
+			volume = mount.Source + ":" + mount.Target
+		}
+		if mount.ReadOnly {
+			volume += ":ro"
+		}
+		volumes = append(volumes, volume)
+	}
+	*v = volumes
+
+	return nil
+}
+
+// ComposeCommand is either a shell string or an exec list
+type ComposeCommand []string
+
+func (c *ComposeCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = []string{"CMD-SHELL", node.Value}
+		return nil
+	}
+
+	var parts []string
+	if decodeErr := node.Decode(&parts); decodeErr != nil {
+		return decodeErr
+	}
+	*c = parts
+
+	return nil
+}
+
+// splitShellWords splits a command string into arguments the way a POSIX shell does, honouring
+// single quotes, double quotes and backslash escapes, without expanding anything
+func splitShellWords(command string) ([]string, error) {
+	words := []string{}
+	word := strings.Builder{}
+	inWord := false
+
+	for i := 0; i < len(command); i++ {
+		char := command[i]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case char == '\\':
+			// A backslash before a newline continues the line
+			if i+1 < len(command) {
+				i++
+				if command[i] != '\n' {
+					word.WriteByte(command[i])
+					inWord = true
+				}
+			}
+
+		case char == '\'':
+			inWord = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in %q", command)
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+
+		case char == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(command); i++ {
+				if command[i] == '"' {
+					closed = true
+					break
+				}
+				// Inside double quotes a backslash only escapes these
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0 {
+					i++
+					if command[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(command[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote in %q", command)
+			}
+
+		default:
+			inWord = true
+			word.WriteByte(char)
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func splitEnvEntry(entry string) (string, string) {
+	for i := 0; i < len(entry); i++ {
+		if entry[i] == '=' {
+			return entry[:i], entry[i+1:]
+		}
+	}
+
+	return entry, ""
+}
+
+// FindComposeFile resolves a compose file from a file or a folder holding one
+func FindComposeFile(path string) (string, error) {
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		return "", statErr
+	}
+
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	for _, fileName := range ComposeFileNames {
+		candidate := filepath.Join(path, fileName)
+		if _, candidateErr := os.Stat(candidate); candidateErr == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no compose file in %s", path)
+}
+
+// interpolate substitutes ${VAR}, $VAR and the ${VAR:-default}, ${VAR-default}, ${VAR:?error},
+// ${VAR?error}, ${VAR:+replacement} and ${VAR+replacement} forms the way compose does. Values come
+// from env first, then the process environment, then the .env file next to the compose file.
+func interpolate(content string, composeDir string, env map[string]string) (string, error) {
+	dotEnv, _ := godotenv.Read(filepath.Join(composeDir, ".env"))
+
+	lookup := func(name string) (string, bool) {
+		if value, exists := env[name]; exists {
+			return value, true
+		}
+		if value, exists := os.LookupEnv(name); exists {
+			return value, true
+		}
+		value, exists := dotEnv[name]
+		return value, exists
+	}
+
+	var interpolateErr error
+
+	// $$ escapes a literal $
+	content = strings.ReplaceAll(content, "$$", "\x00")
+	content = os.Expand(content, func(reference string) string {
+		separator := strings.IndexAny(reference, ":-?+")
+		if separator < 0 {
+			value, _ := lookup(reference)
+			return value
+		}
+
+		name, operator, argument := reference[:separator], reference[separator:separator+1], reference[separator+1:]
+		// With a colon an empty value counts as unset
+		orEmpty := false
+		if operator == ":" && argument != "" {
+			orEmpty, operator, argument = true, argument[:1], argument[1:]
+		}
+
+		value, exists := lookup(name)
+		isSet := exists && (!orEmpty || value != "")
+
+		switch operator {
+		case "-":
+			if !isSet {
+				return argument
+			}
+		case "?":
+			if !isSet {
+				if argument == "" {
+					argument = "required variable is missing a value"
+				}
+				if interpolateErr == nil {
+					interpolateErr = fmt.Errorf("%s: %s", name, argument)
+				}
+			}
+		case "+":
+			if isSet {
+				return argument
+			}
+			return ""
+		default:
+			if interpolateErr == nil {
+				interpolateErr = fmt.Errorf("invalid interpolation ${%s}", reference)
+			}
+		}
+
+		return value
+	})
+
+	return strings.ReplaceAll(content, "\x00", "$"), interpolateErr
+}
+
+// LoadComposeFile parses a compose file or the compose file in a folder, interpolating variables
+// from env, the process environment and the .env file
+func LoadComposeFile(path string, env map[string]string) (ComposeFile, error) {
+	var composeFile ComposeFile
+
+	composePath, findErr := FindComposeFile(path)
+	if findErr != nil {
+		return composeFile, findErr
+	}
+
+	fileBytes, fileReadErr := ioutil.ReadFile(composePath)
+	if fileReadErr != nil {
+		return composeFile, fileReadErr
+	}
+
+	content, interpolateErr := interpolate(string(fileBytes), filepath.Dir(composePath), env)
+	if interpolateErr != nil {
+		return composeFile, fmt.Errorf("%s: %s", composePath, interpolateErr.Error())
+	}
+	if parseErr := yaml.Unmarshal([]byte(content), &composeFile); parseErr != nil {
+		return composeFile, fmt.Errorf("%s: %s", composePath, parseErr.Error())
+	}
+
+	absPath, absErr := filepath.Abs(composePath)
+	if absErr != nil {
+		return composeFile, absErr
+	}
+	composeFile.Path = absPath
+
+	return composeFile, nil
+}
+
+// ServiceNames lists the services of the compose file, sorted
+func (f *ComposeFile) ServiceNames() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
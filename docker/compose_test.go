@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// writeComposeFile writes a compose file to a temporary folder, returning its path
+func writeComposeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if writeErr := ioutil.WriteFile(path, []byte(content), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	return path
+}
+
+func TestComposeServiceUnsupportedKeys(t *testing.T) {
+	composeFile, loadErr := LoadComposeFile(writeComposeFile(t, `
+version: "3.8"
+x-common: &common
+  image: mysql:8.0
+x-tuned: &tuned
+  restart: unless-stopped
+services:
+  db:
+    <<: *common
+    x-note: extension keys are ignored
+    volumes:
+      - /var/lib/mysql
+  cache:
+    <<: [*common, *tuned]
+  worker:
+    image: worker
+    restart: always
+    entrypoint: /bin/sh
+volumes:
+  data: {}
+`), nil)
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+
+	db := composeFile.Services["db"]
+	if supportedErr := db.Supported(); supportedErr != nil {
+		t.Fatalf("db: %s", supportedErr)
+	}
+
+	// Keys merged from an anchor count too
+	cache := composeFile.Services["cache"]
+	if supportedErr := cache.Supported(); supportedErr == nil || supportedErr.Error() != "unsupported compose key restart" {
+		t.Fatalf("cache: %v, want unsupported compose key restart", supportedErr)
+	}
+
+	worker := composeFile.Services["worker"]
+	if supportedErr := worker.Supported(); supportedErr == nil || supportedErr.Error() != "unsupported compose key restart" {
+		t.Fatalf("worker: %v, want unsupported compose key restart", supportedErr)
+	}
+
+	ref := ServiceRef{File: composeFile, Project: "app", Service: "worker"}
+	if _, definitionErr := ref.definition(); definitionErr == nil {
+		t.Fatal("worker definition accepted")
+	}
+}
+
+func TestContainerConfigVolumes(t *testing.T) {
+	composeFile, loadErr := LoadComposeFile(writeComposeFile(t, `
+services:
+  db:
+    image: mysql:8.0
+    volumes:
+      - /var/lib/mysql
+      - data:/data
+      - ./conf:/etc/mysql/conf.d:ro
+      - type: volume
+        target: /cache
+`), nil)
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+
+	ref := ServiceRef{File: composeFile, Project: "app", Service: "db"}
+	config, hostConfig, configErr := ref.containerConfig(composeFile.Services["db"])
+	if configErr != nil {
+		t.Fatal(configErr)
+	}
+
+	for _, anonymous := range []string{"/var/lib/mysql", "/cache"} {
+		if _, declared := config.Volumes[anonymous]; !declared {
+			t.Fatalf("anonymous volume %s missing from %v", anonymous, config.Volumes)
+		}
+	}
+
+	wantBinds := []string{"app_data:/data", filepath.Join(filepath.Dir(composeFile.Path), "conf") + ":/etc/mysql/conf.d:ro"}
+	if len(hostConfig.Binds) != len(wantBinds) {
+		t.Fatalf("binds %v, want %v", hostConfig.Binds, wantBinds)
+	}
+	for i, bind := range wantBinds {
+		if hostConfig.Binds[i] != bind {
+			t.Fatalf("bind %d is %s, want %s", i, hostConfig.Binds[i], bind)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	composeDir := t.TempDir()
+	if writeErr := ioutil.WriteFile(filepath.Join(composeDir, ".env"), []byte("DOT=dot\nSHELL_SET=dot\nPROFILE_SET=dot\nEMPTY=\n"), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	t.Setenv("SHELL_SET", "shell")
+	t.Setenv("PROFILE_SET", "shell")
+	env := map[string]string{"PROFILE_SET": "profile"}
+
+	tests := []struct {
+		content string
+		want    string
+		wantErr bool
+	}{
+		{content: "${DOT}", want: "dot"},
+		{content: "$DOT", want: "dot"},
+		{content: "${SHELL_SET}", want: "shell"},
+		{content: "${PROFILE_SET}", want: "profile"},
+		{content: "${MISSING}", want: ""},
+		{content: "$$DOT", want: "$DOT"},
+		{content: "${MISSING:-fallback}", want: "fallback"},
+		{content: "${EMPTY:-fallback}", want: "fallback"},
+		{content: "${MISSING-fallback}", want: "fallback"},
+		{content: "${EMPTY-fallback}", want: ""},
+		{content: "${DOT:-fallback}", want: "dot"},
+		{content: "${DOT:?must be set}", want: "dot"},
+		{content: "${EMPTY?must be set}", want: ""},
+		{content: "${EMPTY:?must be set}", wantErr: true},
+		{content: "${MISSING?must be set}", wantErr: true},
+		{content: "${DOT:+set}", want: "set"},
+		{content: "${EMPTY:+set}", want: ""},
+		{content: "${EMPTY+set}", want: "set"},
+		{content: "${DOT:}", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			interpolated, interpolateErr := interpolate(test.content, composeDir, env)
+			if test.wantErr {
+				if interpolateErr == nil {
+					t.Fatalf("interpolated to %q, want an error", interpolated)
+				}
+				return
+			}
+			if interpolateErr != nil {
+				t.Fatal(interpolateErr)
+			}
+			if interpolated != test.want {
+				t.Fatalf("interpolated to %q, want %q", interpolated, test.want)
+			}
+		})
+	}
+}
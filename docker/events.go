@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"strconv"
+	"strings"
+)
+
+// ContainerEvents subscribes to the events of every container
+func ContainerEvents(ctx context.Context) (<-chan events.Message, <-chan error) {
+	return dockerClient.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(filters.Arg("type", "container")),
+	})
+}
+
+// EventAction is the action of an event without its detail, e.g. health_status for
+// "health_status: healthy"
+func EventAction(message events.Message) (string, string) {
+	parts := strings.SplitN(message.Action, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// EventExitCode is the exit code of a die event
+func EventExitCode(message events.Message) int {
+	exitCode, _ := strconv.Atoi(message.Actor.Attributes["exitCode"])
+	return exitCode
+}
+
+// EventContainer describes the container of an event from its attributes, which hold its name,
+// image and labels
+func EventContainer(message events.Message) types.Container {
+	return types.Container{
+		ID:     message.Actor.ID,
+		Names:  []string{"/" + message.Actor.Attributes["name"]},
+		Image:  message.Actor.Attributes["image"],
+		Labels: message.Actor.Attributes,
+	}
+}
+
+// ContainerRestartsItself reports whether docker restarts the container on its own when it dies
+func ContainerRestartsItself(ctx context.Context, containerID string) (bool, error) {
+	containerInspect, inspectErr := dockerClient.ContainerInspect(ctx, containerID)
+	if inspectErr != nil {
+		return false, inspectErr
+	}
+
+	if containerInspect.State.Restarting {
+		return true, nil
+	}
+
+	policy := containerInspect.HostConfig.RestartPolicy
+	return !policy.IsNone() && !(policy.IsOnFailure() && containerInspect.State.ExitCode == 0), nil
+}
@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"io"
+)
+
+// ContainerExec runs a command inside a container, returning its exit code and combined output
+func ContainerExec(ctx context.Context, containerID string, cmd []string) (int, string, error) {
+	created, createErr := dockerClient.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
+	})
+	if createErr != nil {
+		return 0, "", createErr
+	}
+
+	attached, attachErr := dockerClient.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if attachErr != nil {
+		return 0, "", attachErr
+	}
+	defer attached.Close()
+
+	execOutput := &bytes.Buffer{}
+	if _, copyErr := stdcopy.StdCopy(execOutput, execOutput, attached.Reader); copyErr != nil {
+		return 0, "", copyErr
+	}
+
+	inspected, inspectErr := dockerClient.ContainerExecInspect(ctx, created.ID)
+	if inspectErr != nil {
+		return 0, "", inspectErr
+	}
+
+	return inspected.ExitCode, execOutput.String(), nil
+}
+
+// ContainerLogLines streams the log lines of the current run of a container, from its start, until
+// ctx is done. Lines logged before a restart are left out.
+func ContainerLogLines(ctx context.Context, containerID string, lines chan<- string) error {
+	inspected, inspectErr := dockerClient.ContainerInspect(ctx, containerID)
+	if inspectErr != nil {
+		return inspectErr
+	}
+
+	logs, logsErr := dockerClient.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Since:      inspected.State.StartedAt,
+	})
+	if logsErr != nil {
+		return logsErr
+	}
+	defer logs.Close()
+
+	// Without a TTY, stdout and stderr are multiplexed into one stream
+	var logReader io.Reader = logs
+	if !inspected.Config.Tty {
+		pipeReader, pipeWriter := io.Pipe()
+		go func() {
+			_, copyErr := stdcopy.StdCopy(pipeWriter, pipeWriter, logs)
+			_ = pipeWriter.CloseWithError(copyErr)
+		}()
+		logReader = pipeReader
+	}
+
+	scanner := bufio.NewScanner(logReader)
+	for scanner.Scan() {
+		select {
+		case lines <- scanner.Text():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return scanner.Err()
+}
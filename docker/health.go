@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"strings"
+	"time"
+)
+
+const (
+	// Health checks that keep a status emit no event, an occasional inspect picks up their output
+	healthReinspectInterval = 2 * time.Second
+)
+
+// healthWatch tracks the health check log entries already sent while waiting on a container
+type healthWatch struct {
+	containerID string
+	logs        chan<- string
+	logsSent    map[time.Time]bool
+}
+
+// check inspects the container, sends new health check output and reports whether it is healthy.
+// A container that stopped and will not be restarted is an error.
+func (w *healthWatch) check(ctx context.Context) (bool, error) {
+	containerInspect, inspectErr := dockerClient.ContainerInspect(ctx, w.containerID)
+	if inspectErr != nil {
+		return false, inspectErr
+	}
+
+	if containerInspect.State.Health == nil {
+		return false, errors.New("container has no health check")
+	}
+
+	for _, logEntry := range containerInspect.State.Health.Log {
+		if w.logsSent[logEntry.Start] {
+			continue
+		}
+		w.logsSent[logEntry.Start] = true
+
+		select {
+		case w.logs <- strings.TrimSpace(logEntry.Output):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	if !containerInspect.State.Running && !containerInspect.State.Restarting {
+		if containerInspect.State.OOMKilled {
+			return false, fmt.Errorf("container was killed out of memory, exit code %d", containerInspect.State.ExitCode)
+		}
+		return false, fmt.Errorf("container exited with code %d", containerInspect.State.ExitCode)
+	}
+
+	return containerInspect.State.Health.Status == types.Healthy, nil
+}
+
+// WaitHealthy follows a container's health on the docker events stream, sending new health check
+// output to logs. Returns nil once the container is healthy, and an error as soon as it exits
+// without restarting or ctx is done.
+func WaitHealthy(ctx context.Context, containerID string, logs chan<- string) error {
+
+	// Subscribe before the first inspect so no event is missed in between
+	eventsCtx, cancelEvents := context.WithCancel(ctx)
+	defer cancelEvents()
+	messages, eventErrs := dockerClient.Events(eventsCtx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", "container"),
+			filters.Arg("container", containerID),
+		),
+	})
+
+	watch := healthWatch{containerID: containerID, logs: logs, logsSent: map[time.Time]bool{}}
+
+	healthy, checkErr := watch.check(ctx)
+	if checkErr != nil || healthy {
+		return checkErr
+	}
+
+	reinspect := time.NewTicker(healthReinspectInterval)
+	defer reinspect.Stop()
+
+	for {
+		select {
+		case message := <-messages:
+			action := strings.SplitN(message.Action, ":", 2)[0]
+			switch action {
+			case "health_status", "die", "oom", "restart", "exec_die":
+			default:
+				continue
+			}
+
+		case <-reinspect.C:
+
+		case eventErr := <-eventErrs:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return eventErr
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		healthy, checkErr = watch.check(ctx)
+		if checkErr != nil || healthy {
+			return checkErr
+		}
+	}
+}
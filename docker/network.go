@@ -2,21 +2,180 @@ package docker
 
 import (
 	"context"
+	"fmt"
 	"github.com/docker/docker/api/types"
-	"github.com/poloniex/polo-local-dev/output"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"net"
 )
 
-func CreateNetwork(name string) error {
+const (
+	// Labels on networks pld creates
+	LabelManaged = "io.pld.managed"
+	LabelNetwork = "io.pld.network"
 
-	// Get all docker networks
-	networks, networkListErr := dockerClient.NetworkList(context.Background(), types.NetworkListOptions{})
+	defaultNetworkDriver = "bridge"
+)
+
+// NetworkSpec describes a network pld makes sure exists
+type NetworkSpec struct {
+	Name       string
+	Driver     string
+	Subnet     string
+	Gateway    string
+	Internal   bool
+	Attachable bool
+	Labels     map[string]string
+}
+
+func (s *NetworkSpec) driver() string {
+	if s.Driver == "" {
+		return defaultNetworkDriver
+	}
+
+	return s.Driver
+}
+
+// FindNetwork looks a network up by its exact name, returning nil when it does not exist
+func FindNetwork(ctx context.Context, name string) (*types.NetworkResource, error) {
+	networks, networkListErr := dockerClient.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", name)),
+	})
+	if networkListErr != nil {
+		return nil, networkListErr
+	}
+
+	// The name filter matches substrings
+	for _, existing := range networks {
+		if existing.Name == name {
+			return &existing, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// NetworkSubnets lists the subnets configured on a network
+func NetworkSubnets(resource types.NetworkResource) []string {
+	subnets := []string{}
+	for _, ipamConfig := range resource.IPAM.Config {
+		if ipamConfig.Subnet != "" {
+			subnets = append(subnets, ipamConfig.Subnet)
+		}
+	}
+
+	return subnets
+}
+
+// CheckNetwork compares an existing network with its spec
+func CheckNetwork(resource types.NetworkResource, spec NetworkSpec) error {
+	if resource.Driver != spec.driver() {
+		return fmt.Errorf("network %s uses driver %s, expected %s", spec.Name, resource.Driver, spec.driver())
+	}
+
+	if spec.Subnet == "" {
+		return nil
+	}
+
+	for _, subnet := range NetworkSubnets(resource) {
+		if subnet == spec.Subnet {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("network %s has subnet %v, expected %s", spec.Name, NetworkSubnets(resource), spec.Subnet)
+}
+
+// CreateNetwork creates a network from its spec, labelled as owned by pld
+func CreateNetwork(ctx context.Context, spec NetworkSpec) error {
+	labels := map[string]string{}
+	for name, value := range spec.Labels {
+		labels[name] = value
+	}
+	labels[LabelManaged] = "true"
+	labels[LabelNetwork] = spec.Name
+
+	options := types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         spec.driver(),
+		Internal:       spec.Internal,
+		Attachable:     spec.Attachable,
+		Labels:         labels,
+	}
+
+	if spec.Subnet != "" {
+		options.IPAM = &network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: spec.Subnet, Gateway: spec.Gateway}},
+		}
+	}
+
+	_, createErr := dockerClient.NetworkCreate(ctx, spec.Name, options)
+
+	return createErr
+}
+
+// EnsureNetwork creates a network unless it exists, in which case it must match the spec. Reports
+// whether the network was created.
+func EnsureNetwork(ctx context.Context, spec NetworkSpec) (bool, error) {
+	existing, findErr := FindNetwork(ctx, spec.Name)
+	if findErr != nil {
+		return false, findErr
+	}
+
+	if existing != nil {
+		return false, CheckNetwork(*existing, spec)
+	}
+
+	if createErr := CreateNetwork(ctx, spec); createErr != nil {
+		return false, createErr
+	}
+
+	return true, nil
+}
+
+// Networks lists every docker network
+func Networks(ctx context.Context) ([]types.NetworkResource, error) {
+	return dockerClient.NetworkList(ctx, types.NetworkListOptions{})
+}
+
+// ManagedNetworks lists the networks pld created, with their attached containers
+func ManagedNetworks(ctx context.Context) ([]types.NetworkResource, error) {
+	networks, networkListErr := dockerClient.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", LabelManaged+"=true")),
+	})
 	if networkListErr != nil {
-		return networkListErr
+		return nil, networkListErr
+	}
+
+	// Listing leaves out containers, inspecting fills them in
+	for networkIndex, managed := range networks {
+		inspected, inspectErr := dockerClient.NetworkInspect(ctx, managed.ID, types.NetworkInspectOptions{})
+		if inspectErr != nil {
+			return nil, inspectErr
+		}
+		networks[networkIndex] = inspected
 	}
 
-	for _, network := range networks {
-		output.Plain(network.Name)
+	return networks, nil
+}
+
+// PruneNetworks removes the networks pld created that no container uses
+func PruneNetworks(ctx context.Context) ([]string, error) {
+	report, pruneErr := dockerClient.NetworksPrune(ctx, filters.NewArgs(filters.Arg("label", LabelManaged+"=true")))
+	if pruneErr != nil {
+		return nil, pruneErr
+	}
+
+	return report.NetworksDeleted, nil
+}
+
+// SubnetsOverlap reports whether two CIDR subnets share addresses
+func SubnetsOverlap(first, second string) bool {
+	_, firstNet, firstErr := net.ParseCIDR(first)
+	_, secondNet, secondErr := net.ParseCIDR(second)
+	if firstErr != nil || secondErr != nil {
+		return false
 	}
 
-	return nil
+	return firstNet.Contains(secondNet.IP) || secondNet.Contains(firstNet.IP)
 }
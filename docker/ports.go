@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"github.com/docker/docker/api/types"
+	"strings"
+)
+
+// PublishedPort is a host port a container publishes
+type PublishedPort struct {
+	Port     int
+	Protocol string
+}
+
+// ContainerPublishedPorts are the host ports a container publishes, each once even when published
+// on both IPv4 and IPv6
+func ContainerPublishedPorts(container types.Container) []PublishedPort {
+	ports := []PublishedPort{}
+	seen := map[PublishedPort]bool{}
+	for _, port := range container.Ports {
+		if port.PublicPort == 0 {
+			continue
+		}
+
+		published := PublishedPort{Port: int(port.PublicPort), Protocol: strings.ToLower(port.Type)}
+		if !seen[published] {
+			ports = append(ports, published)
+			seen[published] = true
+		}
+	}
+
+	return ports
+}
+
+// PublishingContainer finds the container publishing a host port
+func PublishingContainer(containers []types.Container, port int, protocol string) (types.Container, bool) {
+	for _, container := range containers {
+		for _, published := range ContainerPublishedPorts(container) {
+			if published.Port == port && published.Protocol == protocol {
+				return container, true
+			}
+		}
+	}
+
+	return types.Container{}, false
+}
@@ -0,0 +1,597 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-connections/nat"
+	"github.com/joho/godotenv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Labels compose puts on everything it creates, so compose and pld can manage the same containers
+	LabelProject         = "com.docker.compose.project"
+	LabelService         = "com.docker.compose.service"
+	LabelContainerNumber = "com.docker.compose.container-number"
+	LabelOneOff          = "com.docker.compose.oneoff"
+
+	// Fingerprint of the configuration pld created a container with, to recreate it when it changes
+	LabelConfigHash = "io.pld.config-hash"
+)
+
+var (
+	// Compose project names are lowercase letters, digits, dashes and underscores
+	composeProjectNameRegex = regexp.MustCompile(`[^a-z0-9_-]`)
+)
+
+// ComposeProjectName is the name compose uses for the project, from the file's name key or its folder
+func (f *ComposeFile) ComposeProjectName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+
+	return NormalizeProjectName(filepath.Base(filepath.Dir(f.Path)))
+}
+
+// NormalizeProjectName turns a folder name into a compose project name the way compose does
+func NormalizeProjectName(name string) string {
+	return composeProjectNameRegex.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// ServiceRef identifies a service of a compose file
+type ServiceRef struct {
+	File    ComposeFile
+	Project string
+	Service string
+}
+
+func (r *ServiceRef) definition() (ComposeService, error) {
+	service, exists := r.File.Services[r.Service]
+	if !exists {
+		return service, fmt.Errorf("service %s not found in %s", r.Service, r.File.Path)
+	}
+
+	if supportedErr := service.Supported(); supportedErr != nil {
+		return service, fmt.Errorf("%s: %s", r.Service, supportedErr.Error())
+	}
+
+	return service, nil
+}
+
+func (r *ServiceRef) imageName(service ComposeService) string {
+	if service.Image != "" {
+		return service.Image
+	}
+
+	return fmt.Sprintf("%s-%s", r.Project, r.Service)
+}
+
+func (r *ServiceRef) containerName(service ComposeService) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+
+	return fmt.Sprintf("%s-%s-1", r.Project, r.Service)
+}
+
+// networkName resolves a network of the compose file to its docker name. External networks and
+// networks with an explicit name keep it, others belong to the compose project.
+func (r *ServiceRef) networkName(name string) string {
+	if definition, declared := r.File.Networks[name]; declared && (definition.External || definition.Name != "") {
+		if definition.Name != "" {
+			return definition.Name
+		}
+		return name
+	}
+
+	return fmt.Sprintf("%s_%s", r.Project, name)
+}
+
+func (r *ServiceRef) volumeName(name string) string {
+	return fmt.Sprintf("%s_%s", r.Project, name)
+}
+
+// ServiceContainers finds the containers of a compose service by label, stopped ones included when all is set
+func ServiceContainers(ctx context.Context, project, service string, all bool) ([]types.Container, error) {
+	labelFilters := filters.NewArgs(
+		filters.Arg("label", fmt.Sprintf("%s=%s", LabelProject, project)),
+		filters.Arg("label", fmt.Sprintf("%s=%s", LabelService, service)),
+	)
+
+	return dockerClient.ContainerList(ctx, types.ContainerListOptions{All: all, Filters: labelFilters})
+}
+
+// streamMessages relays a docker JSON message stream as progress lines, returning the first error in it
+func streamMessages(stream io.Reader, progress chan<- string) error {
+	decoder := json.NewDecoder(stream)
+	for {
+		var message jsonmessage.JSONMessage
+		if decodeErr := decoder.Decode(&message); decodeErr != nil {
+			if decodeErr == io.EOF {
+				return nil
+			}
+			return decodeErr
+		}
+
+		if message.Error != nil {
+			return message.Error
+		}
+
+		line := strings.TrimRight(message.Stream, "\n")
+		if message.Status != "" {
+			// Only download and extract lines carry progress
+			progressBar := ""
+			if message.Progress != nil {
+				progressBar = message.Progress.String()
+			}
+			line = strings.TrimSpace(fmt.Sprintf("%s %s %s", message.ID, message.Status, progressBar))
+		}
+		if line != "" {
+			progress <- line
+		}
+	}
+}
+
+// buildContext tars a build context folder, leaving out what .dockerignore excludes
+func buildContext(contextDir string) (io.Reader, error) {
+	var excludes []string
+	if ignoreFile, readErr := ioutil.ReadFile(filepath.Join(contextDir, ".dockerignore")); readErr == nil {
+		for _, line := range strings.Split(string(ignoreFile), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				excludes = append(excludes, line)
+			}
+		}
+	}
+
+	matcher, matcherErr := fileutils.NewPatternMatcher(excludes)
+	if matcherErr != nil {
+		return nil, matcherErr
+	}
+
+	buffer := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(buffer)
+
+	walkErr := filepath.Walk(contextDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		relativePath, relErr := filepath.Rel(contextDir, path)
+		if relErr != nil || relativePath == "." {
+			return relErr
+		}
+
+		// The Dockerfile and .dockerignore are always sent, even when ignored
+		if excluded, _ := matcher.Matches(relativePath); excluded && relativePath != "Dockerfile" && relativePath != ".dockerignore" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			var linkErr error
+			if link, linkErr = os.Readlink(path); linkErr != nil {
+				return linkErr
+			}
+		}
+
+		header, headerErr := tar.FileInfoHeader(info, link)
+		if headerErr != nil {
+			return headerErr
+		}
+		header.Name = filepath.ToSlash(relativePath)
+
+		if writeErr := tarWriter.WriteHeader(header); writeErr != nil {
+			return writeErr
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, openErr := os.Open(path)
+		if openErr != nil {
+			return openErr
+		}
+		defer file.Close()
+
+		_, copyErr := io.Copy(tarWriter, file)
+		return copyErr
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	if closeErr := tarWriter.Close(); closeErr != nil {
+		return nil, closeErr
+	}
+
+	return buffer, nil
+}
+
+// BuildService builds the image of a compose service through the docker API. Services without a
+// build section have nothing to build.
+func BuildService(ctx context.Context, ref ServiceRef, progress chan<- string) error {
+	service, serviceErr := ref.definition()
+	if serviceErr != nil {
+		return serviceErr
+	}
+
+	if service.Build == nil {
+		progress <- fmt.Sprintf("%s uses image %s, nothing to build", ref.Service, service.Image)
+		return nil
+	}
+
+	contextDir := service.Build.Context
+	if !filepath.IsAbs(contextDir) {
+		contextDir = filepath.Join(filepath.Dir(ref.File.Path), contextDir)
+	}
+
+	buildContextReader, contextErr := buildContext(contextDir)
+	if contextErr != nil {
+		return contextErr
+	}
+
+	buildArgs := map[string]*string{}
+	for name, value := range service.Build.Args {
+		argValue := value
+		buildArgs[name] = &argValue
+	}
+
+	response, buildErr := dockerClient.ImageBuild(ctx, buildContextReader, types.ImageBuildOptions{
+		Tags:       []string{ref.imageName(service)},
+		Dockerfile: service.Build.Dockerfile,
+		Target:     service.Build.Target,
+		BuildArgs:  buildArgs,
+		Remove:     true,
+		Labels: map[string]string{
+			LabelProject: ref.Project,
+			LabelService: ref.Service,
+		},
+	})
+	if buildErr != nil {
+		return buildErr
+	}
+	defer response.Body.Close()
+
+	return streamMessages(response.Body, progress)
+}
+
+// ensureImage pulls the image of a service, or builds it, when it is not available locally
+func ensureImage(ctx context.Context, ref ServiceRef, service ComposeService, progress chan<- string) error {
+	image := ref.imageName(service)
+	if _, _, inspectErr := dockerClient.ImageInspectWithRaw(ctx, image); inspectErr == nil {
+		return nil
+	} else if !client.IsErrNotFound(inspectErr) {
+		return inspectErr
+	}
+
+	if service.Build != nil {
+		return BuildService(ctx, ref, progress)
+	}
+
+	progress <- fmt.Sprintf("Pulling %s", image)
+	pullStream, pullErr := dockerClient.ImagePull(ctx, image, types.ImagePullOptions{})
+	if pullErr != nil {
+		return pullErr
+	}
+	defer pullStream.Close()
+
+	return streamMessages(pullStream, progress)
+}
+
+// ensureServiceNetwork creates a compose project network when it does not exist yet. External
+// networks are never created by compose, they must exist already.
+func ensureServiceNetwork(ctx context.Context, ref ServiceRef, name string) error {
+	existing, findErr := FindNetwork(ctx, ref.networkName(name))
+	if findErr != nil {
+		return findErr
+	}
+
+	if existing != nil {
+		return nil
+	}
+
+	if ref.File.Networks[name].External {
+		return fmt.Errorf("external network %s not found, declare it in the project's networks", ref.networkName(name))
+	}
+
+	_, createErr := dockerClient.NetworkCreate(ctx, ref.networkName(name), types.NetworkCreate{
+		CheckDuplicate: true,
+		Labels: map[string]string{
+			LabelProject:                 ref.Project,
+			"com.docker.compose.network": name,
+		},
+	})
+
+	return createErr
+}
+
+// containerConfig translates a compose service into container create options
+func (r *ServiceRef) containerConfig(service ComposeService) (*container.Config, *container.HostConfig, error) {
+	exposedPorts, portBindings, portsErr := nat.ParsePortSpecs(service.Ports)
+	if portsErr != nil {
+		return nil, nil, portsErr
+	}
+
+	labels := map[string]string{}
+	for name, value := range service.Labels {
+		labels[name] = value
+	}
+	labels[LabelProject] = r.Project
+	labels[LabelService] = r.Service
+	labels[LabelContainerNumber] = "1"
+	labels[LabelOneOff] = "False"
+
+	// Env files first, environment entries take precedence
+	environment := map[string]string{}
+	for _, envFile := range service.EnvFile {
+		envPath := envFile.Path
+		if !filepath.IsAbs(envPath) {
+			envPath = filepath.Join(filepath.Dir(r.File.Path), envPath)
+		}
+
+		fileEnv, readErr := godotenv.Read(envPath)
+		if errors.Is(readErr, os.ErrNotExist) && !envFile.Required {
+			continue
+		} else if readErr != nil {
+			return nil, nil, fmt.Errorf("env_file %s: %s", envFile.Path, readErr.Error())
+		}
+		for name, value := range fileEnv {
+			environment[name] = value
+		}
+	}
+	for name, value := range service.Environment {
+		environment[name] = value
+	}
+
+	env := []string{}
+	for name, value := range environment {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(env)
+
+	config := &container.Config{
+		Image:        r.imageName(service),
+		Env:          env,
+		Labels:       labels,
+		ExposedPorts: exposedPorts,
+	}
+
+	if len(service.Command) > 0 {
+		if service.Command[0] == "CMD-SHELL" {
+			words, splitErr := splitShellWords(service.Command[1])
+			if splitErr != nil {
+				return nil, nil, fmt.Errorf("command: %s", splitErr.Error())
+			}
+			config.Cmd = words
+		} else {
+			config.Cmd = []string(service.Command)
+		}
+	}
+
+	if service.Healthcheck != nil {
+		health := &container.HealthConfig{
+			Test:    []string(service.Healthcheck.Test),
+			Retries: service.Healthcheck.Retries,
+		}
+		if service.Healthcheck.Disable {
+			health.Test = []string{"NONE"}
+		}
+		for _, duration := range []struct {
+			value  string
+			target *time.Duration
+		}{
+			{service.Healthcheck.Interval, &health.Interval},
+			{service.Healthcheck.Timeout, &health.Timeout},
+			{service.Healthcheck.StartPeriod, &health.StartPeriod},
+		} {
+			if duration.value == "" {
+				continue
+			}
+			parsed, parseErr := time.ParseDuration(duration.value)
+			if parseErr != nil {
+				return nil, nil, fmt.Errorf("healthcheck: %s", parseErr.Error())
+			}
+			*duration.target = parsed
+		}
+		config.Healthcheck = health
+	}
+
+	// Relative bind mounts are relative to the compose file, named volumes belong to the project and
+	// a lone container path is an anonymous volume
+	binds := []string{}
+	for _, volume := range service.Volumes {
+		parts := strings.SplitN(volume, ":", 2)
+		if len(parts) < 2 {
+			if config.Volumes == nil {
+				config.Volumes = map[string]struct{}{}
+			}
+			config.Volumes[parts[0]] = struct{}{}
+			continue
+		}
+		source := parts[0]
+		switch {
+		case strings.HasPrefix(source, "."):
+			source = filepath.Join(filepath.Dir(r.File.Path), source)
+		case strings.HasPrefix(source, "~"):
+			if home, homeErr := os.UserHomeDir(); homeErr == nil {
+				source = filepath.Join(home, source[1:])
+			}
+		case !filepath.IsAbs(source):
+			source = r.volumeName(source)
+		}
+		binds = append(binds, source+":"+parts[1])
+	}
+
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
+		Binds:        binds,
+	}
+
+	return config, hostConfig, nil
+}
+
+// containerConfigHash fingerprints everything a container is created with, the image included, so a
+// rebuilt image or an edited compose file shows as a different hash
+func containerConfigHash(imageID string, config *container.Config, hostConfig *container.HostConfig, networks []string) (string, error) {
+	configJson, jsonErr := json.Marshal(struct {
+		Image      string
+		Config     *container.Config
+		HostConfig *container.HostConfig
+		Networks   []string
+	}{imageID, config, hostConfig, networks})
+	if jsonErr != nil {
+		return "", jsonErr
+	}
+
+	sum := sha256.Sum256(configJson)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// UpService starts a compose service through the docker API, creating its container, networks and
+// image when needed. Dependencies are not started. Returns the container ID.
+func UpService(ctx context.Context, ref ServiceRef, progress chan<- string) (string, error) {
+	service, serviceErr := ref.definition()
+	if serviceErr != nil {
+		return "", serviceErr
+	}
+
+	if imageErr := ensureImage(ctx, ref, service, progress); imageErr != nil {
+		return "", imageErr
+	}
+	image, _, inspectErr := dockerClient.ImageInspectWithRaw(ctx, ref.imageName(service))
+	if inspectErr != nil {
+		return "", inspectErr
+	}
+
+	networks := []string(service.Networks)
+	if len(networks) == 0 {
+		networks = []string{"default"}
+	}
+	for _, networkName := range networks {
+		if networkErr := ensureServiceNetwork(ctx, ref, networkName); networkErr != nil {
+			return "", networkErr
+		}
+	}
+
+	config, hostConfig, configErr := ref.containerConfig(service)
+	if configErr != nil {
+		return "", configErr
+	}
+
+	networkingConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			ref.networkName(networks[0]): {Aliases: []string{ref.Service}},
+		},
+	}
+
+	configHash, hashErr := containerConfigHash(image.ID, config, hostConfig, networks)
+	if hashErr != nil {
+		return "", hashErr
+	}
+	config.Labels[LabelConfigHash] = configHash
+
+	existing, listErr := ServiceContainers(ctx, ref.Project, ref.Service, true)
+	if listErr != nil {
+		return "", listErr
+	}
+
+	// Containers created by compose itself carry no hash and are left to it
+	if len(existing) > 0 {
+		existingHash, hashed := existing[0].Labels[LabelConfigHash]
+		if !hashed || existingHash == configHash {
+			if existing[0].State == "running" {
+				progress <- fmt.Sprintf("%s is running", ref.containerName(service))
+				return existing[0].ID, nil
+			}
+
+			progress <- fmt.Sprintf("Starting %s", ref.containerName(service))
+			if startErr := dockerClient.ContainerStart(ctx, existing[0].ID, types.ContainerStartOptions{}); startErr != nil {
+				return "", startErr
+			}
+			return existing[0].ID, nil
+		}
+
+		progress <- fmt.Sprintf("Recreating %s, its configuration changed", ref.containerName(service))
+		if removeErr := RemoveContainer(ctx, existing[0].ID); removeErr != nil {
+			return "", removeErr
+		}
+	}
+
+	progress <- fmt.Sprintf("Creating %s", ref.containerName(service))
+	created, createErr := dockerClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, ref.containerName(service))
+	if createErr != nil {
+		return "", createErr
+	}
+
+	for _, networkName := range networks[1:] {
+		connectErr := dockerClient.NetworkConnect(ctx, ref.networkName(networkName), created.ID, &network.EndpointSettings{Aliases: []string{ref.Service}})
+		if connectErr != nil {
+			return "", connectErr
+		}
+	}
+
+	progress <- fmt.Sprintf("Starting %s", ref.containerName(service))
+	if startErr := dockerClient.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); startErr != nil {
+		return "", startErr
+	}
+
+	return created.ID, nil
+}
+
+// StopService stops every running container of a compose service
+func StopService(ctx context.Context, ref ServiceRef, progress chan<- string) error {
+	running, listErr := ServiceContainers(ctx, ref.Project, ref.Service, false)
+	if listErr != nil {
+		return listErr
+	}
+
+	if len(running) == 0 {
+		progress <- fmt.Sprintf("%s is not running", ref.Service)
+		return nil
+	}
+
+	for _, runningContainer := range running {
+		progress <- fmt.Sprintf("Stopping %s", runningContainer.ID[:10])
+		if stopErr := StopContainer(ctx, runningContainer.ID); stopErr != nil {
+			return stopErr
+		}
+	}
+
+	return nil
+}
+
+// StopContainer stops a container, giving it compose's default grace period
+func StopContainer(ctx context.Context, containerID string) error {
+	timeout := 10 * time.Second
+	return dockerClient.ContainerStop(ctx, containerID, &timeout)
+}
+
+// RestartContainer restarts a container, giving it compose's default grace period
+func RestartContainer(ctx context.Context, containerID string) error {
+	timeout := 10 * time.Second
+	return dockerClient.ContainerRestart(ctx, containerID, &timeout)
+}
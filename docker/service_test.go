@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+// A pull of a one-layer image as the docker API streams it
+const recordedPullStream = `{"status":"Pulling from library/busybox","id":"latest"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"a58ecd4f0c86"}
+{"status":"Downloading","progressDetail":{"current":22473,"total":2217073},"progress":"[>                                                  ]  22.47kB/2.217MB","id":"a58ecd4f0c86"}
+{"status":"Verifying Checksum","progressDetail":{},"id":"a58ecd4f0c86"}
+{"status":"Download complete","progressDetail":{},"id":"a58ecd4f0c86"}
+{"status":"Extracting","progressDetail":{"current":65536,"total":2217073},"progress":"[=>                                                 ]  65.54kB/2.217MB","id":"a58ecd4f0c86"}
+{"status":"Pull complete","progressDetail":{},"id":"a58ecd4f0c86"}
+{"status":"Digest: sha256:5acba83a746c7608ed544dc1533b87c737a0b0fb730301639a0179f9344b1678"}
+{"status":"Status: Downloaded newer image for busybox:latest"}
+`
+
+// collectProgress runs a stream through streamMessages, returning the progress lines it sent
+func collectProgress(t *testing.T, stream string) ([]string, error) {
+	t.Helper()
+
+	progress := make(chan string, 100)
+	streamErr := streamMessages(strings.NewReader(stream), progress)
+	close(progress)
+
+	lines := []string{}
+	for line := range progress {
+		lines = append(lines, line)
+	}
+
+	return lines, streamErr
+}
+
+func TestStreamMessagesPull(t *testing.T) {
+	lines, streamErr := collectProgress(t, recordedPullStream)
+	if streamErr != nil {
+		t.Fatal(streamErr)
+	}
+
+	if len(lines) != 9 {
+		t.Fatalf("got %d progress lines, want 9: %q", len(lines), lines)
+	}
+	if lines[0] != "latest Pulling from library/busybox" {
+		t.Fatalf("first line %q", lines[0])
+	}
+	if lines[1] != "a58ecd4f0c86 Pulling fs layer" {
+		t.Fatalf("status without progress %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "a58ecd4f0c86 Downloading [") {
+		t.Fatalf("status with progress %q", lines[2])
+	}
+	if lines[8] != "Status: Downloaded newer image for busybox:latest" {
+		t.Fatalf("last line %q", lines[8])
+	}
+}
+
+func TestStreamMessagesBuild(t *testing.T) {
+	stream := `{"stream":"Step 1/2 : FROM busybox\n"}
+{"stream":"\n"}
+{"stream":" ---> 3c19bafed223\n"}
+{"aux":{"ID":"sha256:3c19bafed223"}}
+{"stream":"Successfully built 3c19bafed223\n"}
+`
+	lines, streamErr := collectProgress(t, stream)
+	if streamErr != nil {
+		t.Fatal(streamErr)
+	}
+
+	want := []string{"Step 1/2 : FROM busybox", " ---> 3c19bafed223", "Successfully built 3c19bafed223"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+}
+
+func TestStreamMessagesError(t *testing.T) {
+	stream := `{"status":"Pulling from library/nope","id":"latest"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}
+{"status":"never read"}
+`
+	lines, streamErr := collectProgress(t, stream)
+	if streamErr == nil || streamErr.Error() != "manifest unknown" {
+		t.Fatalf("error %v, want manifest unknown", streamErr)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("got %q before the error", lines)
+	}
+}
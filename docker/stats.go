@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/docker/docker/api/types"
+	"strings"
+)
+
+// ContainerUsage is the resource usage of a container at one point in time
+type ContainerUsage struct {
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryLimit   uint64  `json:"memory_limit"`
+	MemoryPercent float64 `json:"memory_percent"`
+	NetworkRx     uint64  `json:"network_rx"`
+	NetworkTx     uint64  `json:"network_tx"`
+	BlockRead     uint64  `json:"block_read"`
+	BlockWrite    uint64  `json:"block_write"`
+}
+
+// Add sums the usage of another container into this one
+func (u *ContainerUsage) Add(other ContainerUsage) {
+	u.CPUPercent += other.CPUPercent
+	u.MemoryUsage += other.MemoryUsage
+	u.NetworkRx += other.NetworkRx
+	u.NetworkTx += other.NetworkTx
+	u.BlockRead += other.BlockRead
+	u.BlockWrite += other.BlockWrite
+}
+
+// ContainerStats samples the resource usage of a container, computed the way docker stats does
+func ContainerStats(ctx context.Context, containerID string) (ContainerUsage, error) {
+	var usage ContainerUsage
+
+	response, statsErr := dockerClient.ContainerStats(ctx, containerID, false)
+	if statsErr != nil {
+		return usage, statsErr
+	}
+	defer response.Body.Close()
+
+	var stats types.StatsJSON
+	if decodeErr := json.NewDecoder(response.Body).Decode(&stats); decodeErr != nil {
+		return usage, decodeErr
+	}
+
+	// CPU use since the previous sample, over every CPU
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	// Page cache is not counted, cgroup v1 reports it as cache and v2 as inactive_file
+	usage.MemoryUsage = stats.MemoryStats.Usage
+	for _, cacheKey := range []string{"total_inactive_file", "inactive_file", "cache"} {
+		if cache, exists := stats.MemoryStats.Stats[cacheKey]; exists {
+			if cache < usage.MemoryUsage {
+				usage.MemoryUsage -= cache
+			}
+			break
+		}
+	}
+	usage.MemoryLimit = stats.MemoryStats.Limit
+	if usage.MemoryLimit > 0 {
+		usage.MemoryPercent = float64(usage.MemoryUsage) / float64(usage.MemoryLimit) * 100
+	}
+
+	for _, networkStats := range stats.Networks {
+		usage.NetworkRx += networkStats.RxBytes
+		usage.NetworkTx += networkStats.TxBytes
+	}
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			usage.BlockRead += entry.Value
+		case "write":
+			usage.BlockWrite += entry.Value
+		}
+	}
+
+	return usage, nil
+}
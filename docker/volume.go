@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// Image of the helper containers that read and write volumes
+	volumeHelperImage = "busybox:latest"
+
+	// Where helper containers mount the volume
+	volumeHelperPath = "/volume"
+)
+
+// VolumeMount is a named volume mounted by a container
+type VolumeMount struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+}
+
+// ContainerVolumeMounts lists the named volumes a container mounts, stopped containers included
+func ContainerVolumeMounts(ctx context.Context, containerID string) ([]VolumeMount, error) {
+	containerInspect, inspectErr := dockerClient.ContainerInspect(ctx, containerID)
+	if inspectErr != nil {
+		return nil, inspectErr
+	}
+
+	mounts := []VolumeMount{}
+	for _, mount := range containerInspect.Mounts {
+		if mount.Type == "volume" && mount.Name != "" {
+			mounts = append(mounts, VolumeMount{Name: mount.Name, Destination: mount.Destination})
+		}
+	}
+
+	return mounts, nil
+}
+
+// StartContainer starts a stopped container
+func StartContainer(ctx context.Context, containerID string) error {
+	return dockerClient.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
+}
+
+// ensureHelperImage pulls the volume helper image unless it is there already
+func ensureHelperImage(ctx context.Context) error {
+	if _, _, inspectErr := dockerClient.ImageInspectWithRaw(ctx, volumeHelperImage); inspectErr == nil {
+		return nil
+	} else if !client.IsErrNotFound(inspectErr) {
+		return inspectErr
+	}
+
+	pullStream, pullErr := dockerClient.ImagePull(ctx, volumeHelperImage, types.ImagePullOptions{})
+	if pullErr != nil {
+		return pullErr
+	}
+	defer pullStream.Close()
+
+	_, copyErr := io.Copy(ioutil.Discard, pullStream)
+	return copyErr
+}
+
+// withVolumeHelper runs an action against a helper container that mounts the volume, removing the
+// helper afterwards. The helper is created, and only started when it has a command.
+func withVolumeHelper(ctx context.Context, volume string, cmd []string, action func(helperID string) error) error {
+	if imageErr := ensureHelperImage(ctx); imageErr != nil {
+		return imageErr
+	}
+
+	helper, createErr := dockerClient.ContainerCreate(ctx,
+		&container.Config{Image: volumeHelperImage, Cmd: cmd},
+		&container.HostConfig{Binds: []string{fmt.Sprintf("%s:%s", volume, volumeHelperPath)}},
+		nil, nil, "")
+	if createErr != nil {
+		return createErr
+	}
+	defer func() {
+		_ = dockerClient.ContainerRemove(context.Background(), helper.ID, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	if len(cmd) > 0 {
+		if startErr := dockerClient.ContainerStart(ctx, helper.ID, types.ContainerStartOptions{}); startErr != nil {
+			return startErr
+		}
+
+		waitResult, waitErr := dockerClient.ContainerWait(ctx, helper.ID, container.WaitConditionNotRunning)
+		select {
+		case result := <-waitResult:
+			if result.StatusCode != 0 {
+				return fmt.Errorf("%s exited with code %d", cmd[0], result.StatusCode)
+			}
+		case err := <-waitErr:
+			return err
+		}
+	}
+
+	return action(helper.ID)
+}
+
+// SaveVolume writes the content of a volume to a gzipped tar archive
+func SaveVolume(ctx context.Context, volume string, archive io.Writer) error {
+	return withVolumeHelper(ctx, volume, nil, func(helperID string) error {
+		content, _, copyErr := dockerClient.CopyFromContainer(ctx, helperID, volumeHelperPath)
+		if copyErr != nil {
+			return copyErr
+		}
+		defer content.Close()
+
+		gzipWriter := gzip.NewWriter(archive)
+		if _, writeErr := io.Copy(gzipWriter, content); writeErr != nil {
+			return writeErr
+		}
+
+		return gzipWriter.Close()
+	})
+}
+
+// RestoreVolume replaces the content of a volume with an archive written by SaveVolume, creating
+// the volume when it does not exist
+func RestoreVolume(ctx context.Context, volume string, archive io.Reader) error {
+	clearCmd := []string{"find", volumeHelperPath, "-mindepth", "1", "-delete"}
+
+	return withVolumeHelper(ctx, volume, clearCmd, func(helperID string) error {
+		// The archive holds the volume folder itself, docker decompresses it
+		return dockerClient.CopyToContainer(ctx, helperID, "/", archive, types.CopyToContainerOptions{})
+	})
+}
@@ -2,24 +2,56 @@ package git
 
 import (
 	"context"
+	"fmt"
 	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/google/go-github/v47/github"
 	"golang.org/x/oauth2"
+	"net/http"
 	"os"
 )
 
 var (
 	Client *github.Client
+
+	// Protocol used for clone URLs, https or ssh
+	Protocol = "https"
 )
 
 func init() {
+	Client = github.NewClient(httpClient())
+}
+
+func httpClient() *http.Client {
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
 	)
-	tc := oauth2.NewClient(ctx, ts)
 
-	Client = github.NewClient(tc)
+	return oauth2.NewClient(ctx, ts)
+}
+
+// Configure points the client at a GitHub host and sets the clone protocol
+func Configure(host, protocol string) error {
+	if protocol != "" {
+		Protocol = protocol
+	}
+
+	if host == "" || host == "github.com" {
+		return nil
+	}
+
+	// GitHub Enterprise serves the API under /api/v3/
+	baseURL := fmt.Sprintf("https://%s/api/v3/", host)
+	uploadURL := fmt.Sprintf("https://%s/api/uploads/", host)
+	enterpriseClient, clientErr := github.NewEnterpriseClient(baseURL, uploadURL, httpClient())
+	if clientErr != nil {
+		return clientErr
+	}
+	Client = enterpriseClient
+
+	return nil
 }
 
 func GetOrganization() (*github.Organization, error) {
@@ -52,9 +84,78 @@ func ForkRepo(repo *github.Repository) error {
 }
 
 func CloneRepo(destination string, repo *github.Repository) error {
+	url := repo.GetCloneURL()
+	if Protocol == "ssh" {
+		url = repo.GetSSHURL()
+	}
+
+	_, cloneErr := gogit.PlainClone(destination, false, &gogit.CloneOptions{
+		URL: url,
+	})
+
+	return cloneErr
+}
+
+// CloneBranch clones a single branch of any git URL, including local paths and bare repos
+func CloneBranch(destination, url, branch string) error {
 	_, cloneErr := gogit.PlainClone(destination, false, &gogit.CloneOptions{
-		URL: repo.GetCloneURL(),
+		URL:           url,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
 	})
 
 	return cloneErr
 }
+
+// SyncBranch fetches a branch and hard resets the local checkout to it
+func SyncBranch(destination, branch string) error {
+	repo, openErr := gogit.PlainOpen(destination)
+	if openErr != nil {
+		return openErr
+	}
+
+	fetchErr := repo.Fetch(&gogit.FetchOptions{
+		RefSpecs: []gitconfig.RefSpec{
+			gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)),
+		},
+		Force: true,
+	})
+	if fetchErr != nil && fetchErr != gogit.NoErrAlreadyUpToDate {
+		return fetchErr
+	}
+
+	remoteRef, refErr := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if refErr != nil {
+		return refErr
+	}
+
+	worktree, worktreeErr := repo.Worktree()
+	if worktreeErr != nil {
+		return worktreeErr
+	}
+
+	return worktree.Reset(&gogit.ResetOptions{
+		Commit: remoteRef.Hash(),
+		Mode:   gogit.HardReset,
+	})
+}
+
+// Head returns the current branch and short commit SHA of a local repo
+func Head(path string) (string, string, error) {
+	repo, openErr := gogit.PlainOpen(path)
+	if openErr != nil {
+		return "", "", openErr
+	}
+
+	head, headErr := repo.Head()
+	if headErr != nil {
+		return "", "", headErr
+	}
+
+	branch := "HEAD"
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+
+	return branch, head.Hash().String()[:7], nil
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testRemote is a bare repo with a working copy pushing to it
+type testRemote struct {
+	t    *testing.T
+	url  string
+	work *gogit.Repository
+	dir  string
+}
+
+func newTestRemote(t *testing.T) *testRemote {
+	t.Helper()
+
+	bareDir := filepath.Join(t.TempDir(), "remote.git")
+	if _, initErr := gogit.PlainInit(bareDir, true); initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	workDir := t.TempDir()
+	work, initErr := gogit.PlainInit(workDir, false)
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	if _, remoteErr := work.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{bareDir}}); remoteErr != nil {
+		t.Fatal(remoteErr)
+	}
+
+	return &testRemote{t: t, url: bareDir, work: work, dir: workDir}
+}
+
+// commit writes a file in the working copy, commits it and pushes master
+func (r *testRemote) commit(name, content string) {
+	r.t.Helper()
+
+	if writeErr := ioutil.WriteFile(filepath.Join(r.dir, name), []byte(content), 0644); writeErr != nil {
+		r.t.Fatal(writeErr)
+	}
+
+	worktree, worktreeErr := r.work.Worktree()
+	if worktreeErr != nil {
+		r.t.Fatal(worktreeErr)
+	}
+	if _, addErr := worktree.Add(name); addErr != nil {
+		r.t.Fatal(addErr)
+	}
+
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, commitErr := worktree.Commit("update "+name, &gogit.CommitOptions{Author: signature}); commitErr != nil {
+		r.t.Fatal(commitErr)
+	}
+
+	pushErr := r.work.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/master"},
+	})
+	if pushErr != nil && pushErr != gogit.NoErrAlreadyUpToDate {
+		r.t.Fatal(pushErr)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	return string(content)
+}
+
+func TestCloneAndSyncBranch(t *testing.T) {
+	remote := newTestRemote(t)
+	remote.commit("a.txt", "first")
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	if cloneErr := CloneBranch(destination, remote.url, "master"); cloneErr != nil {
+		t.Fatalf("clone: %s", cloneErr)
+	}
+	if content := readFile(t, filepath.Join(destination, "a.txt")); content != "first" {
+		t.Fatalf("cloned a.txt = %q, want %q", content, "first")
+	}
+
+	remote.commit("a.txt", "second")
+	remote.commit("b.txt", "new")
+
+	if syncErr := SyncBranch(destination, "master"); syncErr != nil {
+		t.Fatalf("sync: %s", syncErr)
+	}
+	if content := readFile(t, filepath.Join(destination, "a.txt")); content != "second" {
+		t.Fatalf("synced a.txt = %q, want %q", content, "second")
+	}
+	if content := readFile(t, filepath.Join(destination, "b.txt")); content != "new" {
+		t.Fatalf("synced b.txt = %q, want %q", content, "new")
+	}
+
+	// Up to date is not an error
+	if syncErr := SyncBranch(destination, "master"); syncErr != nil {
+		t.Fatalf("second sync: %s", syncErr)
+	}
+}
+
+func TestCloneBranchMissing(t *testing.T) {
+	remote := newTestRemote(t)
+	remote.commit("a.txt", "first")
+
+	destination := filepath.Join(t.TempDir(), "clone")
+	if cloneErr := CloneBranch(destination, remote.url, "missing"); cloneErr == nil {
+		t.Fatal("clone of a missing branch succeeded")
+	}
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"bufio"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Globs matches paths relative to a repo root against globs in .gitignore syntax
+type Globs []gitignore.Pattern
+
+// ParseGlobs parses globs in .gitignore syntax, relative to the repo root
+func ParseGlobs(globs []string) Globs {
+	patterns := Globs{}
+	for _, glob := range globs {
+		patterns = append(patterns, gitignore.ParsePattern(glob, nil))
+	}
+
+	return patterns
+}
+
+// Match reports whether the last glob matching a path includes it, negated globs excluding again
+func (g Globs) Match(path string, isDir bool) bool {
+	return gitignore.NewMatcher(g).Match(splitPath(path), isDir)
+}
+
+// Ignore matches paths relative to a repo root against its .gitignore files and .git/info/exclude.
+// Folders are added as they are walked, so the .gitignore of a folder applies below it.
+type Ignore struct {
+	root     string
+	patterns Globs
+}
+
+// NewIgnore reads the excludes of a repo and the .gitignore at its root
+func NewIgnore(root string) (*Ignore, error) {
+	ignore := &Ignore{root: root, patterns: Globs{}}
+
+	excludePatterns, excludeErr := readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), nil)
+	if excludeErr != nil {
+		return nil, excludeErr
+	}
+	ignore.patterns = append(ignore.patterns, excludePatterns...)
+
+	return ignore, ignore.AddDir("")
+}
+
+// AddDir reads the .gitignore of a folder, given relative to the repo root
+func (i *Ignore) AddDir(dir string) error {
+	dirPatterns, readErr := readIgnoreFile(filepath.Join(i.root, dir, ".gitignore"), splitPath(dir))
+	if readErr != nil {
+		return readErr
+	}
+	i.patterns = append(i.patterns, dirPatterns...)
+
+	return nil
+}
+
+// Ignored reports whether git ignores a path relative to the repo root. The .git folder always is.
+func (i *Ignore) Ignored(path string, isDir bool) bool {
+	pathParts := splitPath(path)
+	if len(pathParts) > 0 && pathParts[0] == ".git" {
+		return true
+	}
+
+	return i.patterns.Match(path, isDir)
+}
+
+func readIgnoreFile(path string, domain []string) (Globs, error) {
+	patterns := Globs{}
+
+	ignoreFile, openErr := os.Open(path)
+	if os.IsNotExist(openErr) {
+		return patterns, nil
+	} else if openErr != nil {
+		return nil, openErr
+	}
+	defer ignoreFile.Close()
+
+	scanner := bufio.NewScanner(ignoreFile)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") && len(strings.TrimSpace(line)) > 0 {
+			patterns = append(patterns, gitignore.ParsePattern(line, domain))
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+func splitPath(path string) []string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." || path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobsMatch(t *testing.T) {
+	globs := ParseGlobs([]string{"*.log", "!keep.log", "build/", "/vendor", "docs/**/*.md"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "app.log", want: true},
+		{path: "src/app.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "build", want: false},
+		{path: "src/build", isDir: true, want: true},
+		{path: "vendor", isDir: true, want: true},
+		{path: "src/vendor", isDir: true, want: false},
+		{path: "docs/api/orders.md", want: true},
+		{path: "README.md", want: false},
+		{path: "main.go", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := globs.Match(test.path, test.isDir); got != test.want {
+				t.Fatalf("match %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude": "*.swp\n",
+		".gitignore":        "# build output\n\n/dist\n*.tmp\n",
+		"web/.gitignore":    "node_modules/\n!important.tmp\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), os.ModePerm); mkdirErr != nil {
+			t.Fatal(mkdirErr)
+		}
+		if writeErr := ioutil.WriteFile(path, []byte(content), 0644); writeErr != nil {
+			t.Fatal(writeErr)
+		}
+	}
+
+	ignore, ignoreErr := NewIgnore(root)
+	if ignoreErr != nil {
+		t.Fatal(ignoreErr)
+	}
+
+	// The .gitignore of a folder only applies once the folder is added
+	if !ignore.Ignored("web/important.tmp", false) {
+		t.Fatal("web/important.tmp not ignored before web was added")
+	}
+	if addErr := ignore.AddDir("web"); addErr != nil {
+		t.Fatal(addErr)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: ".git", isDir: true, want: true},
+		{path: ".git/HEAD", want: true},
+		{path: "main.go.swp", want: true},
+		{path: "dist", isDir: true, want: true},
+		{path: "web/dist", isDir: true, want: false},
+		{path: "cache.tmp", want: true},
+		{path: "web/important.tmp", want: false},
+		{path: "web/node_modules", isDir: true, want: true},
+		{path: "node_modules", isDir: true, want: false},
+		{path: "web/index.js", want: false},
+		{path: "# build output", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := ignore.Ignored(test.path, test.isDir); got != test.want {
+				t.Fatalf("ignored %v, want %v", got, test.want)
+			}
+		})
+	}
+}
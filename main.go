@@ -1,14 +1,12 @@
 package main
 
 import (
-	"fmt"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/poloniex/polo-local-dev/cmd"
-	_ "github.com/poloniex/polo-local-dev/config"
+	"github.com/poloniex/polo-local-dev/output"
 )
 
 func main() {
-	fmt.Println("")
 	cmd.Execute()
-	fmt.Println("")
+	output.Newline()
 }
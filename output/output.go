@@ -3,11 +3,15 @@ package output
 import (
 	"bufio"
 	"container/list"
+	"encoding/json"
 	"fmt"
 	"golang.org/x/term"
+	"io"
 	"log"
 	"math"
 	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
@@ -20,20 +24,70 @@ var red = color.New(color.FgRed).SprintFunc()
 var green = color.New(color.FgGreen).SprintFunc()
 var yellow = color.New(color.FgYellow).SprintFunc()
 
+// Shown in every title, e.g. the active profile
+var titleContext string
+
+// In JSON mode stdout only carries JSON, everything else goes to stderr
+var jsonMode bool
+var writer io.Writer = os.Stdout
+
+func SetTitleContext(context string) {
+	titleContext = context
+}
+
+func SetJSON(enabled bool) {
+	jsonMode = enabled
+	writer = os.Stdout
+	if enabled {
+		writer = os.Stderr
+	}
+}
+
+// ValueOutputAnnotation marks commands whose stdout only carries the value they print, for scripts
+const ValueOutputAnnotation = "value-output"
+
+// SetStderr sends all output to stderr, leaving stdout to what a command prints itself
+func SetStderr() {
+	writer = os.Stderr
+}
+
+func JSONMode() bool {
+	return jsonMode
+}
+
+func JSON(content interface{}) error {
+	contentJson, jsonErr := json.MarshalIndent(content, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	fmt.Println(string(contentJson))
+
+	return nil
+}
+
+// Newline pads output with an empty line
+func Newline() {
+	_, _ = fmt.Fprintln(writer, "")
+}
+
 func Title(content string) {
-	fmt.Println("┏" + strings.Repeat("━", len(content)+2) + "┓")
-	fmt.Printf("┃ %s ┃\n", content)
-	fmt.Println("┗" + strings.Repeat("━", len(content)+2) + "┛")
+	if titleContext != "" {
+		content = fmt.Sprintf("%s (%s)", content, titleContext)
+	}
+	_, _ = fmt.Fprintln(writer, "┏"+strings.Repeat("━", len(content)+2)+"┓")
+	_, _ = fmt.Fprintf(writer, "┃ %s ┃\n", content)
+	_, _ = fmt.Fprintln(writer, "┗"+strings.Repeat("━", len(content)+2)+"┛")
 }
 
 func Section(content string) {
-	fmt.Printf("\n   %s \n", content)
-	fmt.Println("  " + strings.Repeat("━", len(content)+2))
-	fmt.Println("")
+	_, _ = fmt.Fprintf(writer, "\n   %s \n", content)
+	_, _ = fmt.Fprintln(writer, "  "+strings.Repeat("━", len(content)+2))
+	_, _ = fmt.Fprintln(writer, "")
 }
 
 func Ok(content string) {
-	fmt.Print(OkString(content))
+	_, _ = fmt.Fprint(writer, OkString(content))
 }
 
 func OkString(content string) string {
@@ -42,7 +96,7 @@ func OkString(content string) string {
 }
 
 func Warning(content string) {
-	fmt.Print(WarningString(content))
+	_, _ = fmt.Fprint(writer, WarningString(content))
 }
 
 func WarningString(content string) string {
@@ -51,7 +105,7 @@ func WarningString(content string) string {
 }
 
 func Error(content string) {
-	fmt.Print(ErrorString(content))
+	_, _ = fmt.Fprint(writer, ErrorString(content))
 }
 
 func ErrorString(content string) string {
@@ -62,7 +116,7 @@ func ErrorString(content string) string {
 func Plain(content string) {
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	for scanner.Scan() {
-		fmt.Print(PlainString(scanner.Text()))
+		_, _ = fmt.Fprint(writer, PlainString(scanner.Text()))
 	}
 }
 
@@ -84,7 +138,18 @@ func FifoOutput(title string, lines int, content <-chan string, closeSignal <-ch
 	// TODO: Test this in headless execution
 	terminalWidth, _, err := term.GetSize(0)
 	if err != nil {
-		return
+
+		// Without a terminal there is nothing to redraw, relay the output as it comes
+		for {
+			select {
+			case newContent := <-content:
+				cleanContent, _ := ansi.Strip([]byte(newContent))
+				Plain(prefix + string(cleanContent))
+			case <-closeSignal:
+				finished <- true
+				return
+			}
+		}
 	}
 
 	// Determine max length of output strings
@@ -203,7 +268,7 @@ func InputCancelFunc(waitFunc func(chan<- bool), timeout time.Duration, status c
 	for {
 		select {
 		case finishState := <-funcSuccess:
-			status <- true
+			status <- finishState
 			return finishState
 
 		case <-input:
@@ -216,3 +281,23 @@ func InputCancelFunc(waitFunc func(chan<- bool), timeout time.Duration, status c
 		}
 	}
 }
+
+// Notify shows a desktop notification, ringing the terminal bell where there is none
+func Notify(title, message string) {
+	var notifyCmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		notifyCmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	case "linux":
+		notifyCmd = exec.Command("notify-send", title, message)
+	}
+
+	if notifyCmd == nil || notifyCmd.Run() != nil {
+		Bell()
+	}
+}
+
+// Bell rings the terminal bell
+func Bell() {
+	_, _ = fmt.Fprint(writer, "\a")
+}